@@ -10,6 +10,10 @@ var (
 	fakeMessage = "Test logging, but use a somewhat realistic message length."
 )
 
+const (
+	fakeFormat = "Test logging with format, %s: %d"
+)
+
 func BenchmarkNewDefault(b *testing.B) {
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
@@ -51,6 +55,30 @@ func BenchmarkLogDisabled(b *testing.B) {
 	})
 }
 
+func BenchmarkLogDisabledNamed(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithLevel(InfoLevel)
+	l.WithNamedLevel("app.cache", DebugLevel)
+	l = l.Named("app").Named("db")
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Debug().Msg(fakeMessage).Fire()
+		}
+	})
+}
+
+func BenchmarkLogDisabledVModule(b *testing.B) {
+	vm, _ := NewVModule("server=1,db/*=trace")
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithLevel(InfoLevel).WithVModule(vm)
+	var site VSite
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.V(&site, DebugLevel).Msg(fakeMessage).Fire()
+		}
+	})
+}
+
 func BenchmarkLogMsg(b *testing.B) {
 	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard))
 	b.ResetTimer()
@@ -61,6 +89,36 @@ func BenchmarkLogMsg(b *testing.B) {
 	})
 }
 
+func BenchmarkLogDisabledMsgf(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithLevel(InfoLevel)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Debug().Msgf(fakeFormat, "count", 100).Fire()
+		}
+	})
+}
+
+func BenchmarkLogDisabledFiref(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithLevel(InfoLevel)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Debug().Firef(fakeFormat, "count", 100)
+		}
+	})
+}
+
+func BenchmarkLogMsgf(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard))
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Debug().Msgf(fakeFormat, "count", 100).Fire()
+		}
+	})
+}
+
 func BenchmarkLogFields(b *testing.B) {
 	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard))
 	b.ResetTimer()
@@ -77,6 +135,122 @@ func BenchmarkLogFields(b *testing.B) {
 	})
 }
 
+func BenchmarkLogFieldsLogfmt(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithEncoderFunc(LogfmtEncoder)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info().
+				String("string", "four!").
+				Time("time", time.Time{}, "").
+				Int("int", 123).
+				Float32("float", -2.203230293249593).
+				Msg(fakeMessage).
+				Fire()
+		}
+	})
+}
+
+func BenchmarkLogFieldsJSON(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithEncoderFunc(JSONEncoder)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info().
+				String("string", "four!").
+				Time("time", time.Time{}, "").
+				Int("int", 123).
+				Float32("float", -2.203230293249593).
+				Msg(fakeMessage).
+				Fire()
+		}
+	})
+}
+
+func BenchmarkLogFieldsRecordFields(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithEncoderFunc(JSONEncoder).WithRecordFields(true)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info().
+				String("string", "four!").
+				Time("time", time.Time{}, "").
+				Int("int", 123).
+				Float32("float", -2.203230293249593).
+				Msg(fakeMessage).
+				Fire()
+		}
+	})
+}
+
+func BenchmarkLogFieldsMultiEncoder(b *testing.B) {
+	l := NewDefault().WithExporter(MultipleExporter(
+		EncoderExporter(JSONEncoder, StandardExporter(ioutil.Discard)),
+		EncoderExporter(TextEncoder, StandardExporter(ioutil.Discard)),
+	))
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info().
+				String("string", "four!").
+				Time("time", time.Time{}, "").
+				Int("int", 123).
+				Float32("float", -2.203230293249593).
+				Msg(fakeMessage).
+				Fire()
+		}
+	})
+}
+
+func BenchmarkLogFieldsCBOR(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithEncoderFunc(CBOREncoder)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info().
+				String("string", "four!").
+				Time("time", time.Time{}, "").
+				Int("int", 123).
+				Float32("float", -2.203230293249593).
+				Msg(fakeMessage).
+				Fire()
+		}
+	})
+}
+
+func BenchmarkLogFieldsProto(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithEncoderFunc(ProtoEncoder)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info().
+				String("string", "four!").
+				Time("time", time.Time{}, "").
+				Int("int", 123).
+				Float32("float", -2.203230293249593).
+				Msg(fakeMessage).
+				Fire()
+		}
+	})
+}
+
+func BenchmarkLogFieldsJSONConfig(b *testing.B) {
+	f := JSONEncoderFunc(&JSONEncoderConfig{MessageKey: "msg", TimeKey: "@timestamp", LevelFormat: LevelFormatUpper})
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithEncoderFunc(f)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info().
+				String("string", "four!").
+				Time("time", time.Time{}, "").
+				Int("int", 123).
+				Float32("float", -2.203230293249593).
+				Msg(fakeMessage).
+				Fire()
+		}
+	})
+}
+
 func BenchmarkLogWithFields(b *testing.B) {
 	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard))
 	l.WithFields().AddString("string", "four")
@@ -114,6 +288,72 @@ func BenchmarkLog10Fields(b *testing.B) {
 	})
 }
 
+func BenchmarkLog10FieldsJSON(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithEncoderFunc(JSONEncoder)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Debug().
+				Msg("Test logger out").
+				String("String1", "Value1").
+				String("String2", "Value2").
+				String("String2", "Value3").
+				Byte("Byte", 'a').
+				Bytes("Bytes", []byte("abc")).
+				Int64("Int64", 64).
+				Uint64("Uint64", 64).
+				Float64("Float64", 99.99).
+				Bool("Bool", true).
+				Fire()
+		}
+	})
+}
+
+func BenchmarkLog10FieldsCBOR(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithEncoderFunc(CBOREncoder)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Debug().
+				Msg("Test logger out").
+				String("String1", "Value1").
+				String("String2", "Value2").
+				String("String2", "Value3").
+				Byte("Byte", 'a').
+				Bytes("Bytes", []byte("abc")).
+				Int64("Int64", 64).
+				Uint64("Uint64", 64).
+				Float64("Float64", 99.99).
+				Bool("Bool", true).
+				Fire()
+		}
+	})
+}
+
+func BenchmarkLog10FieldsProto(b *testing.B) {
+	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard)).WithEncoderFunc(ProtoEncoder)
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Debug().
+				Msg("Test logger out").
+				String("String1", "Value1").
+				String("String2", "Value2").
+				String("String2", "Value3").
+				Byte("Byte", 'a').
+				Bytes("Bytes", []byte("abc")).
+				Int64("Int64", 64).
+				Uint64("Uint64", 64).
+				Float64("Float64", 99.99).
+				Bool("Bool", true).
+				Fire()
+		}
+	})
+}
+
 func BenchmarkLog10String(b *testing.B) {
 	l := NewDefault().WithExporter(StandardExporter(ioutil.Discard))
 	b.ResetTimer()
@@ -31,11 +31,15 @@ func FromContext(ctx context.Context) *Logger {
 }
 
 // FromContextDefault get *Logger from context.
-// And it will return a default *Logger if no *Logger was set before.
+// And it will return the package-level Logger if no *Logger was set before.
+//
+// NOTICE: The package-level Logger is shared, so the With* methods called on
+// it change the Global; Call Clone first to configure a separate Logger, and
+// WithAtomicLevel for a separate level.
 func FromContextDefault(ctx context.Context) *Logger {
 	l, ok := ctx.Value(ctxLogKey{}).(*Logger)
 	if !ok {
-		return NewDefault()
+		return Global()
 	}
 	return l
 }
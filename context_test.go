@@ -37,4 +37,14 @@ func TestFromContext(t *testing.T) {
 func TestFromContextDefault(t *testing.T) {
 	l := FromContextDefault(context.Background())
 	require.NotNil(t, l)
+	require.True(t, l == Global())
+
+	// The Clone is configured without changing the Global.
+	level := Global().Level()
+	cl := FromContextDefault(context.Background()).Clone().WithAtomicLevel(NewAtomicLevel(PanicLevel))
+	require.Equal(t, PanicLevel, cl.Level())
+	require.Equal(t, level, Global().Level())
+
+	nl := NewDefault()
+	require.True(t, FromContextDefault(WithContext(context.Background(), nl)) == nl)
 }
@@ -1,6 +1,12 @@
 package glog
 
-import "time"
+import (
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/yu31/glog/pkg/buffer"
+)
 
 // EncoderFunc used to return a new Encoder instances.
 type EncoderFunc func() Encoder
@@ -69,7 +75,9 @@ type BuildEncoder interface {
 	AddMsg(msg string)
 	AddEntryTime(t time.Time, layout string)
 	AddLevel(level Level)
-	AddCaller(skip int)
+	// AddCaller add the caller's file and line formatted by the layout;
+	// the function name is added too if frame.Function is not empty.
+	AddCaller(frame runtime.Frame, layout int8)
 
 	// AddBeginMarker add the begin marker.
 	AddBeginMarker()
@@ -82,6 +90,14 @@ type BuildEncoder interface {
 	WriteIn(p []byte) error
 }
 
+// StackEncoder is an optional interface implemented by the Encoder that supports
+// the stack trace; The stack trace is omitted for the Encoder without it.
+type StackEncoder interface {
+	// AddStack add the stack trace by the program counters returned by runtime.Callers,
+	// nothing is added if pcs is empty.
+	AddStack(pcs []uintptr)
+}
+
 type Encoder interface {
 	ObjectEncoder
 	BuildEncoder
@@ -91,3 +107,21 @@ type Encoder interface {
 	// Close callers must not retain references to the Encoder after calling Close.
 	Close() error
 }
+
+// addStack adds the stack trace into enc if it implements StackEncoder.
+func addStack(enc Encoder, pcs []uintptr) {
+	if se, ok := enc.(StackEncoder); ok {
+		se.AddStack(pcs)
+	}
+}
+
+// appendComplex appends the complex number formatted as "r+ii" or "r-ii".
+func appendComplex(buf *buffer.Buffer, r, i float64) {
+	buf.AppendFloat(r, 64)
+	// The sign of negative imaginary part and +Inf is written by AppendFloat.
+	if math.IsNaN(i) || (!math.Signbit(i) && !math.IsInf(i, 1)) {
+		buf.AppendByte('+')
+	}
+	buf.AppendFloat(i, 64)
+	buf.AppendByte('i')
+}
@@ -0,0 +1,376 @@
+package glog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yu31/glog/pkg/buffer"
+)
+
+var (
+	_ Encoder      = (*cborEncoder)(nil)
+	_ StackEncoder = (*cborEncoder)(nil)
+)
+
+var _cborBufferPool = buffer.NewPool()
+
+// The major types of CBOR, see RFC 8949 section 3.1.
+const (
+	cborMajorUint   byte = 0 << 5
+	cborMajorNegint byte = 1 << 5
+	cborMajorBytes  byte = 2 << 5
+	cborMajorText   byte = 3 << 5
+	cborMajorArray  byte = 4 << 5
+	cborMajorMap    byte = 5 << 5
+	cborMajorTag    byte = 6 << 5
+	cborMajorSimple byte = 7 << 5
+)
+
+const (
+	cborFalse      byte = cborMajorSimple | 20
+	cborTrue       byte = cborMajorSimple | 21
+	cborNull       byte = cborMajorSimple | 22
+	cborFloat16    byte = cborMajorSimple | 25
+	cborFloat32    byte = cborMajorSimple | 26
+	cborFloat64    byte = cborMajorSimple | 27
+	cborBreak      byte = cborMajorSimple | 31
+	cborIndefinite byte = 31
+
+	// cborTagDateTime is the tag of standard date/time string.
+	cborTagDateTime = 0
+	// cborTagEpochTime is the tag of epoch-based date/time.
+	cborTagEpochTime = 1
+	// cborTagJSON is the tag of embedded JSON, see https://www.iana.org/assignments/cbor-tags.
+	cborTagJSON = 262
+)
+
+// CBOREncoder return a new encoder implements by cborEncoder.
+//
+// Every entry is encoded as a CBOR (RFC 8949) map of indefinite length, so the
+// output of exporter is a CBOR sequence (RFC 8742) without line break.
+// The raw bytes and interface are encoded as the embedded JSON (tag 262),
+// the raw bytes that is not a valid JSON is encoded as a text string.
+// Use CBORToJSON to convert the output for human reading.
+func CBOREncoder() Encoder { return newCBOREncoder() }
+
+func newCBOREncoder() *cborEncoder {
+	enc := &cborEncoder{
+		buf: _cborBufferPool.Get(),
+	}
+	return enc
+}
+
+type cborEncoder struct {
+	buf *buffer.Buffer
+}
+
+// Bytes Implements encoder.
+func (enc *cborEncoder) Bytes() []byte {
+	return enc.buf.Bytes()
+}
+func (enc *cborEncoder) Close() error {
+	enc.buf.Free()
+	enc.buf = nil
+	return nil
+}
+
+// AddBeginMarker Implements BuildEncoder.
+func (enc *cborEncoder) AddBeginMarker() { enc.buf.AppendByte(cborMajorMap | cborIndefinite) }
+func (enc *cborEncoder) AddEndMarker()   { enc.buf.AppendByte(cborBreak) }
+func (enc *cborEncoder) AddLineBreak()   {}
+func (enc *cborEncoder) AddMsg(msg string) {
+	enc.appendString("message")
+	enc.appendString(msg)
+}
+func (enc *cborEncoder) AddEntryTime(t time.Time, layout string) {
+	enc.appendString("time")
+	enc.appendTime(t, layout)
+}
+func (enc *cborEncoder) AddLevel(level Level) {
+	enc.appendString("level")
+	enc.appendString(level.String())
+}
+func (enc *cborEncoder) AddCaller(frame runtime.Frame, layout int8) {
+	enc.appendString("caller")
+	tmp := _cborBufferPool.Get()
+	tmp.AppendString(CallerFile(frame.File, layout))
+	tmp.AppendByte(':')
+	tmp.AppendInt(int64(frame.Line))
+	enc.appendTextBytes(tmp.Bytes())
+	tmp.Free()
+	if frame.Function != "" {
+		enc.appendString("func")
+		enc.appendString(CallerFunc(frame.Function, layout))
+	}
+}
+func (enc *cborEncoder) AddStack(pcs []uintptr) {
+	if len(pcs) == 0 {
+		return
+	}
+	enc.appendString("stack")
+	enc.buf.AppendByte(cborMajorArray | cborIndefinite)
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		enc.appendHead(cborMajorMap, 3)
+		enc.appendString("func")
+		enc.appendString(frame.Function)
+		enc.appendString("file")
+		enc.appendString(frame.File)
+		enc.appendString("line")
+		enc.appendInt64(int64(frame.Line))
+		if !more {
+			break
+		}
+	}
+	enc.buf.AppendByte(cborBreak)
+}
+func (enc *cborEncoder) WriteIn(p []byte) error {
+	_, err := enc.buf.Write(p)
+	return err
+}
+
+// AddByte Implements ObjectEncoder.
+func (enc *cborEncoder) AddByte(k string, b byte)       { enc.appendString(k); enc.appendUint64(uint64(b)) }
+func (enc *cborEncoder) AddString(k string, s string)   { enc.appendString(k); enc.appendString(s) }
+func (enc *cborEncoder) AddBool(k string, v bool)       { enc.appendString(k); enc.appendBool(v) }
+func (enc *cborEncoder) AddInt64(k string, i int64)     { enc.appendString(k); enc.appendInt64(i) }
+func (enc *cborEncoder) AddUnt64(k string, i uint64)    { enc.appendString(k); enc.appendUint64(i) }
+func (enc *cborEncoder) AddFloat64(k string, f float64) { enc.appendString(k); enc.appendFloat(f) }
+func (enc *cborEncoder) AddComplex128(k string, c complex128) {
+	enc.appendString(k)
+	enc.appendComplex128(c)
+}
+func (enc *cborEncoder) AddRawBytes(k string, bs []byte) { enc.appendString(k); enc.appendRawBytes(bs) }
+func (enc *cborEncoder) AddRawString(k string, s string) { enc.appendString(k); enc.appendRawString(s) }
+func (enc *cborEncoder) AddTime(k string, t time.Time, layout string) {
+	enc.appendString(k)
+	enc.appendTime(t, layout)
+}
+func (enc *cborEncoder) AddDuration(k string, d time.Duration, layout int8) {
+	enc.appendString(k)
+	enc.appendDuration(d, layout)
+}
+func (enc *cborEncoder) AddArray(k string, am ArrayMarshaler) error {
+	enc.appendString(k)
+	return enc.appendArray(am)
+}
+func (enc *cborEncoder) AddObject(k string, om ObjectMarshaler) error {
+	enc.appendString(k)
+	return enc.appendObject(om)
+}
+func (enc *cborEncoder) AddInterface(k string, i interface{}) error {
+	enc.appendString(k)
+	return enc.appendInterface(i)
+}
+
+// AppendByte Implements FieldEncoder.
+func (enc *cborEncoder) AppendByte(b byte)             { enc.appendUint64(uint64(b)) }
+func (enc *cborEncoder) AppendString(s string)         { enc.appendString(s) }
+func (enc *cborEncoder) AppendBool(v bool)             { enc.appendBool(v) }
+func (enc *cborEncoder) AppendInt64(i int64)           { enc.appendInt64(i) }
+func (enc *cborEncoder) AppendUnt64(i uint64)          { enc.appendUint64(i) }
+func (enc *cborEncoder) AppendFloat64(f float64)       { enc.appendFloat(f) }
+func (enc *cborEncoder) AppendComplex128(c complex128) { enc.appendComplex128(c) }
+func (enc *cborEncoder) AppendRawBytes(bs []byte)      { enc.appendRawBytes(bs) }
+func (enc *cborEncoder) AppendRawString(s string)      { enc.appendRawString(s) }
+func (enc *cborEncoder) AppendDuration(d time.Duration, layout int8) {
+	enc.appendDuration(d, layout)
+}
+func (enc *cborEncoder) AppendTime(t time.Time, layout string) {
+	enc.appendTime(t, layout)
+}
+func (enc *cborEncoder) AppendArray(am ArrayMarshaler) error {
+	return enc.appendArray(am)
+}
+func (enc *cborEncoder) AppendObject(om ObjectMarshaler) error {
+	return enc.appendObject(om)
+}
+func (enc *cborEncoder) AppendInterface(i interface{}) error {
+	return enc.appendInterface(i)
+}
+
+// appendHead adds the initial byte and the following argument of a data item
+// in the shortest form.
+func (enc *cborEncoder) appendHead(major byte, n uint64) {
+	switch {
+	case n < 24:
+		enc.buf.AppendByte(major | byte(n))
+	case n <= math.MaxUint8:
+		enc.buf.AppendByte(major | 24)
+		enc.buf.AppendByte(byte(n))
+	case n <= math.MaxUint16:
+		enc.buf.AppendByte(major | 25)
+		enc.buf.AppendByte(byte(n >> 8))
+		enc.buf.AppendByte(byte(n))
+	case n <= math.MaxUint32:
+		enc.buf.AppendByte(major | 26)
+		enc.buf.AppendByte(byte(n >> 24))
+		enc.buf.AppendByte(byte(n >> 16))
+		enc.buf.AppendByte(byte(n >> 8))
+		enc.buf.AppendByte(byte(n))
+	default:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], n)
+		enc.buf.AppendByte(major | 27)
+		_, _ = enc.buf.Write(b[:])
+	}
+}
+
+// appendString appends s as a text string, the invalid UTF-8 bytes are
+// replaced with the replacement character because the text string must be UTF-8.
+func (enc *cborEncoder) appendString(s string) {
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
+	enc.appendHead(cborMajorText, uint64(len(s)))
+	enc.buf.AppendString(s)
+}
+
+func (enc *cborEncoder) appendTextBytes(bs []byte) {
+	if !utf8.Valid(bs) {
+		bs = bytes.ToValidUTF8(bs, []byte(string(utf8.RuneError)))
+	}
+	enc.appendHead(cborMajorText, uint64(len(bs)))
+	_, _ = enc.buf.Write(bs)
+}
+
+// appendRawBytes appends bs as the embedded JSON,
+// or a text string if it's not a valid JSON.
+func (enc *cborEncoder) appendRawBytes(bs []byte) {
+	if !json.Valid(bs) {
+		enc.appendTextBytes(bs)
+		return
+	}
+	enc.appendHead(cborMajorTag, cborTagJSON)
+	enc.appendHead(cborMajorBytes, uint64(len(bs)))
+	_, _ = enc.buf.Write(bs)
+}
+
+// appendRawString appends s as the embedded JSON,
+// or a text string if it's not a valid JSON.
+func (enc *cborEncoder) appendRawString(s string) {
+	if !json.Valid([]byte(s)) {
+		enc.appendString(s)
+		return
+	}
+	enc.appendHead(cborMajorTag, cborTagJSON)
+	enc.appendHead(cborMajorBytes, uint64(len(s)))
+	enc.buf.AppendString(s)
+}
+
+func (enc *cborEncoder) appendBool(v bool) {
+	if v {
+		enc.buf.AppendByte(cborTrue)
+	} else {
+		enc.buf.AppendByte(cborFalse)
+	}
+}
+
+func (enc *cborEncoder) appendInt64(i int64) {
+	if i < 0 {
+		enc.appendHead(cborMajorNegint, uint64(-(i + 1)))
+		return
+	}
+	enc.appendHead(cborMajorUint, uint64(i))
+}
+
+func (enc *cborEncoder) appendUint64(i uint64) {
+	enc.appendHead(cborMajorUint, i)
+}
+
+func (enc *cborEncoder) appendFloat(f float64) {
+	// Use the single-precision if it's lossless.
+	if f32 := float32(f); float64(f32) == f || math.IsNaN(f) {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], math.Float32bits(f32))
+		enc.buf.AppendByte(cborFloat32)
+		_, _ = enc.buf.Write(b[:])
+		return
+	}
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], math.Float64bits(f))
+	enc.buf.AppendByte(cborFloat64)
+	_, _ = enc.buf.Write(b[:])
+}
+
+func (enc *cborEncoder) appendTime(t time.Time, layout string) {
+	switch layout {
+	case TimeFormatUnixSecond:
+		enc.appendHead(cborMajorTag, cborTagEpochTime)
+		enc.appendInt64(t.Unix())
+	case TimeFormatUnixMilli:
+		enc.appendInt64(t.UnixNano() / 1e6)
+	case TimeFormatUnixMicro:
+		enc.appendInt64(t.UnixNano() / 1e3)
+	case TimeFormatUnixNano:
+		enc.appendInt64(t.UnixNano())
+	default:
+		if layout == time.RFC3339 || layout == time.RFC3339Nano {
+			enc.appendHead(cborMajorTag, cborTagDateTime)
+		}
+		tmp := _cborBufferPool.Get()
+		tmp.AppendTime(t, layout)
+		enc.appendTextBytes(tmp.Bytes())
+		tmp.Free()
+	}
+}
+
+func (enc *cborEncoder) appendDuration(d time.Duration, layout int8) {
+	tmp := _cborBufferPool.Get()
+	AppendDuration(tmp, d, layout)
+	enc.appendTextBytes(tmp.Bytes())
+	tmp.Free()
+}
+
+func (enc *cborEncoder) appendComplex128(c complex128) {
+	// Cast to a platform-independent, fixed-size type.
+	r, i := float64(real(c)), float64(imag(c))
+	tmp := _cborBufferPool.Get()
+	appendComplex(tmp, r, i)
+	enc.appendTextBytes(tmp.Bytes())
+	tmp.Free()
+}
+
+func (enc *cborEncoder) appendArray(am ArrayMarshaler) error {
+	enc.buf.AppendByte(cborMajorArray | cborIndefinite)
+	err := am.MarshalGLogArray(enc)
+	enc.buf.AppendByte(cborBreak)
+	return err
+}
+
+func (enc *cborEncoder) appendObject(om ObjectMarshaler) error {
+	enc.buf.AppendByte(cborMajorMap | cborIndefinite)
+	err := om.MarshalGLogObject(enc)
+	enc.buf.AppendByte(cborBreak)
+	return err
+}
+
+func (enc *cborEncoder) appendInterface(i interface{}) error {
+	var err error
+	var b []byte
+
+	switch m := i.(type) {
+	case json.Marshaler:
+		b, err = m.MarshalJSON()
+	case nil:
+		enc.buf.AppendByte(cborNull)
+		return nil
+	default:
+		b, err = json.Marshal(i)
+	}
+
+	if err != nil {
+		enc.appendString(fmt.Sprintf("%+v", i))
+		return err
+	}
+	enc.appendRawBytes(b)
+	return nil
+}
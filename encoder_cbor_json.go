@@ -0,0 +1,350 @@
+package glog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/yu31/glog/pkg/buffer"
+)
+
+// cborMaxDepth is the maximum nesting depth of arrays and maps in CBORToJSON.
+const cborMaxDepth = 256
+
+var (
+	errCBORMaxDepth    = errors.New("glog: cbor nesting depth exceeds the limit")
+	errCBORUnexpected  = errors.New("glog: cbor unexpected break code")
+	errCBORInvalidHead = errors.New("glog: cbor invalid additional information")
+)
+
+// CBORToJSON reads the CBOR sequence from src, e.g. the output of CBOREncoder,
+// and writes each top-level data item as a line of JSON into dst.
+//
+// The byte strings are encoded as base64 strings, the embedded JSON (tag 262)
+// is written as is if it's valid or as a string otherwise, and the other tags are ignored.
+func CBORToJSON(dst io.Writer, src io.Reader) error {
+	d := &cborDecoder{
+		r:   bufio.NewReader(src),
+		buf: _cborBufferPool.Get(),
+	}
+	defer d.buf.Free()
+
+	for {
+		if _, err := d.r.Peek(1); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		d.buf.Reset()
+		if err := d.decode(0); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		d.buf.AppendByte('\n')
+		if _, err := dst.Write(d.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+type cborDecoder struct {
+	r   *bufio.Reader
+	buf *buffer.Buffer
+}
+
+// readHead reads the initial byte and its argument,
+// the info is cborIndefinite if the data item has indefinite length.
+func (d *cborDecoder) readHead() (major byte, info byte, n uint64, err error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return
+	}
+	major, info = b&0xe0, b&0x1f
+	switch {
+	case info < 24:
+		n = uint64(info)
+	case info <= 27:
+		var arr [8]byte
+		size := 1 << (info - 24)
+		if _, err = io.ReadFull(d.r, arr[8-size:]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(arr[:])
+	case info == cborIndefinite:
+	default:
+		err = errCBORInvalidHead
+	}
+	return
+}
+
+// isBreak consumes the break code if it's the next byte.
+func (d *cborDecoder) isBreak() (bool, error) {
+	b, err := d.r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	if b[0] != cborBreak {
+		return false, nil
+	}
+	_, err = d.r.ReadByte()
+	return true, err
+}
+
+func (d *cborDecoder) decode(depth int) error {
+	if depth > cborMaxDepth {
+		return errCBORMaxDepth
+	}
+	major, info, n, err := d.readHead()
+	if err != nil {
+		return err
+	}
+	if info == cborIndefinite && (major == cborMajorUint || major == cborMajorNegint || major == cborMajorTag) {
+		return errCBORInvalidHead
+	}
+
+	switch major {
+	case cborMajorUint:
+		d.buf.AppendUint(n)
+	case cborMajorNegint:
+		if n > math.MaxInt64 {
+			// The -1-n overflows int64, write it in decimal directly.
+			d.buf.AppendByte('-')
+			if n == math.MaxUint64 {
+				d.buf.AppendString("18446744073709551616")
+			} else {
+				d.buf.AppendUint(n + 1)
+			}
+			return nil
+		}
+		d.buf.AppendInt(-1 - int64(n))
+	case cborMajorBytes, cborMajorText:
+		s, err := d.readString(major, info, n)
+		if err != nil {
+			return err
+		}
+		d.buf.AppendByte('"')
+		if major == cborMajorBytes {
+			d.buf.AppendString(base64.StdEncoding.EncodeToString(s))
+		} else {
+			appendStringEscapeBytes(d.buf, s)
+		}
+		d.buf.AppendByte('"')
+	case cborMajorArray:
+		d.buf.AppendByte('[')
+		for i := uint64(0); info == cborIndefinite || i < n; i++ {
+			if info == cborIndefinite {
+				ok, err := d.isBreak()
+				if err != nil {
+					return err
+				}
+				if ok {
+					break
+				}
+			}
+			if i > 0 {
+				d.buf.AppendByte(',')
+			}
+			if err := d.decode(depth + 1); err != nil {
+				return err
+			}
+		}
+		d.buf.AppendByte(']')
+	case cborMajorMap:
+		d.buf.AppendByte('{')
+		for i := uint64(0); info == cborIndefinite || i < n; i++ {
+			if info == cborIndefinite {
+				ok, err := d.isBreak()
+				if err != nil {
+					return err
+				}
+				if ok {
+					break
+				}
+			}
+			if i > 0 {
+				d.buf.AppendByte(',')
+			}
+			if err := d.decodeKey(depth + 1); err != nil {
+				return err
+			}
+			d.buf.AppendByte(':')
+			if err := d.decode(depth + 1); err != nil {
+				return err
+			}
+		}
+		d.buf.AppendByte('}')
+	case cborMajorTag:
+		if n == cborTagJSON {
+			major, info, n, err := d.readHead()
+			if err != nil {
+				return err
+			}
+			if major != cborMajorBytes && major != cborMajorText {
+				return fmt.Errorf("glog: cbor embedded JSON must be a string, got major type %d", major>>5)
+			}
+			s, err := d.readString(major, info, n)
+			if err != nil {
+				return err
+			}
+			if !json.Valid(s) {
+				// Write the invalid JSON as a string.
+				d.buf.AppendByte('"')
+				appendStringEscapeBytes(d.buf, s)
+				d.buf.AppendByte('"')
+				return nil
+			}
+			_, _ = d.buf.Write(s)
+			return nil
+		}
+		return d.decode(depth + 1)
+	default:
+		return d.decodeSimple(info, n)
+	}
+	return nil
+}
+
+// decodeKey decodes the key of map, the key that is not a string is quoted.
+func (d *cborDecoder) decodeKey(depth int) error {
+	b, err := d.r.Peek(1)
+	if err != nil {
+		return err
+	}
+	if b[0]&0xe0 == cborMajorText {
+		return d.decode(depth)
+	}
+	// Decode the key into a temporary buffer and quote it.
+	buf, tmp := d.buf, _cborBufferPool.Get()
+	defer tmp.Free()
+	d.buf = tmp
+	err = d.decode(depth)
+	d.buf = buf
+	if err != nil {
+		return err
+	}
+	d.buf.AppendByte('"')
+	appendStringEscapeBytes(d.buf, tmp.Bytes())
+	d.buf.AppendByte('"')
+	return nil
+}
+
+func (d *cborDecoder) decodeSimple(info byte, n uint64) error {
+	var f float64
+	switch cborMajorSimple | info {
+	case cborFalse:
+		d.buf.AppendBool(false)
+		return nil
+	case cborTrue:
+		d.buf.AppendBool(true)
+		return nil
+	case cborNull, cborMajorSimple | 23:
+		// The undefined is written as null.
+		d.buf.AppendString("null")
+		return nil
+	case cborFloat16:
+		f = float16ToFloat64(uint16(n))
+	case cborFloat32:
+		f = float64(math.Float32frombits(uint32(n)))
+	case cborFloat64:
+		f = math.Float64frombits(n)
+	case cborBreak:
+		return errCBORUnexpected
+	default:
+		// The unassigned simple values.
+		d.buf.AppendUint(n)
+		return nil
+	}
+	switch {
+	case math.IsNaN(f):
+		d.buf.AppendString(`"NaN"`)
+	case math.IsInf(f, 1):
+		d.buf.AppendString(`"+Inf"`)
+	case math.IsInf(f, -1):
+		d.buf.AppendString(`"-Inf"`)
+	default:
+		d.buf.AppendFloat(f, 64)
+	}
+	return nil
+}
+
+// readString reads the content of byte string or text string, the chunks of
+// indefinite length string are concatenated.
+func (d *cborDecoder) readString(major byte, info byte, n uint64) ([]byte, error) {
+	if info != cborIndefinite {
+		if n > math.MaxInt32 {
+			return nil, fmt.Errorf("glog: cbor string length %d is too large", n)
+		}
+		// The buffer grows with the data actually read rather than the declared
+		// length, so that a corrupted length does not allocate a huge slice.
+		var b bytes.Buffer
+		if _, err := io.CopyN(&b, d.r, int64(n)); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+	var s []byte
+	for {
+		ok, err := d.isBreak()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return s, nil
+		}
+		cMajor, cInfo, cn, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if cMajor != major || cInfo == cborIndefinite {
+			return nil, fmt.Errorf("glog: cbor invalid chunk of indefinite length string")
+		}
+		chunk, err := d.readString(cMajor, cInfo, cn)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, chunk...)
+	}
+}
+
+// appendStringEscapeBytes is like AppendStringEscape but for the bytes.
+func appendStringEscapeBytes(buf *buffer.Buffer, s []byte) {
+	for _, b := range s {
+		if !noEscapeTable[b] {
+			AppendStringEscape(buf, string(s))
+			return
+		}
+	}
+	_, _ = buf.Write(s)
+}
+
+// float16ToFloat64 converts the IEEE 754 half-precision float.
+func float16ToFloat64(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1.0
+	}
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	default:
+		return sign * math.Ldexp(mant+1024, exp-25)
+	}
+}
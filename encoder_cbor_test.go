@@ -0,0 +1,193 @@
+package glog
+
+import (
+	"bytes"
+	hexenc "encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCBOREncoder_AddObject(t *testing.T) {
+	enc := CBOREncoder()
+	defer func() {
+		_ = enc.Close()
+	}()
+
+	tm := time.Date(2020, 11, 4, 18, 6, 13, 0, time.UTC)
+	var infos infos
+	infos = append(infos, &info{
+		Name:  "aa",
+		Sex:   "man",
+		Age:   999,
+		Times: timeArray{tm, tm},
+	})
+	infos = append(infos, &info{
+		Name:  "bb",
+		Sex:   "man",
+		Age:   -999,
+		Times: timeArray{},
+	})
+
+	enc.AddBeginMarker()
+	require.Nil(t, enc.AddArray("infos", infos))
+	enc.AddEndMarker()
+
+	var b bytes.Buffer
+	require.Nil(t, CBORToJSON(&b, bytes.NewReader(enc.Bytes())))
+	require.Equal(t, `{"infos":[{"name":"aa","sex":"man","age":999,"times":["2020-11-04T18:06:13Z","2020-11-04T18:06:13Z"]},`+
+		`{"name":"bb","sex":"man","age":-999,"times":[]}]}`+"\n", b.String())
+}
+
+func TestCBOREncoder_WithLogger(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithEncoderFunc(CBOREncoder).WithExporter(StandardExporter(&b)).WithCaller(true)
+	l.WithFields().AddString("rid", "xxxxxx01")
+
+	for i := 0; i < 2; i++ {
+		l.Info().
+			Msg("test cbor").
+			String("String", "Value \"quoted\"\n").
+			Strings("Strings", []string{"a", "b"}).
+			Byte("Byte", 'a').
+			Bool("Bool", true).
+			Int("Int", -1).
+			Int64("Int64", math.MinInt64).
+			Uint64("Uint64", math.MaxUint64).
+			Float32("Float32", 1.5).
+			Float64("Float64", 99.99).
+			Float64("NaN", math.NaN()).
+			Complex128("Complex128", 1+2i).
+			Complex128("NegComplex128", 1-2i).
+			Millisecond("Duration", time.Millisecond*1500).
+			Time("Time", time.Unix(1604484373, 0), TimeFormatUnixSecond).
+			RawBytes("RawBytes", []byte(`{"a":[1,2]}`)).
+			Any("Interface", map[string]int{"x": 1}).
+			Any("Nil", nil).
+			Error("Error", errors.New("failed")).
+			Fire()
+	}
+
+	var out bytes.Buffer
+	require.Nil(t, CBORToJSON(&out, &b))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Equal(t, 2, len(lines))
+
+	m := make(map[string]interface{})
+	d := json.NewDecoder(strings.NewReader(lines[1]))
+	d.UseNumber()
+	require.Nil(t, d.Decode(&m), lines[1])
+	require.Equal(t, "test cbor", m["message"])
+	require.Equal(t, "info", m["level"])
+	require.Equal(t, "xxxxxx01", m["rid"])
+	require.Equal(t, "Value \"quoted\"\n", m["String"])
+	require.Equal(t, []interface{}{"a", "b"}, m["Strings"])
+	require.Equal(t, json.Number("97"), m["Byte"])
+	require.Equal(t, true, m["Bool"])
+	require.Equal(t, json.Number("-1"), m["Int"])
+	require.Equal(t, json.Number("-9223372036854775808"), m["Int64"])
+	require.Equal(t, json.Number("18446744073709551615"), m["Uint64"])
+	require.Equal(t, json.Number("1.5"), m["Float32"])
+	require.Equal(t, json.Number("99.99"), m["Float64"])
+	require.Equal(t, "NaN", m["NaN"])
+	require.Equal(t, "1+2i", m["Complex128"])
+	require.Equal(t, "1-2i", m["NegComplex128"])
+	require.Equal(t, "1500ms", m["Duration"])
+	require.Equal(t, json.Number("1604484373"), m["Time"])
+	require.Equal(t, map[string]interface{}{"a": []interface{}{json.Number("1"), json.Number("2")}}, m["RawBytes"])
+	require.Equal(t, map[string]interface{}{"x": json.Number("1")}, m["Interface"])
+	require.Nil(t, m["Nil"])
+	require.Equal(t, "failed", m["Error"].(map[string]interface{})["msg"])
+	require.Contains(t, m["caller"], "encoder_cbor_test.go:")
+	_, err := time.Parse(defaultTimeLayout, m["time"].(string))
+	require.Nil(t, err)
+}
+
+func TestCBORToJSON(t *testing.T) {
+	// The examples in RFC 8949 Appendix A.
+	cases := map[string]string{
+		"00":                 `0`,
+		"1903e8":             `1000`,
+		"1bffffffffffffffff": `18446744073709551615`,
+		"3903e7":             `-1000`,
+		"3bffffffffffffffff": `-18446744073709551616`,
+		"f90000":             `0`,
+		"f93c00":             `1`,
+		"f9c400":             `-4`,
+		"f90001":             `0.00000005960464477539063`,
+		"f97c00":             `"+Inf"`,
+		"f97e00":             `"NaN"`,
+		"fa47c35000":         `100000`,
+		"fb3ff199999999999a": `1.1`,
+		"f4":                 `false`,
+		"f5":                 `true`,
+		"f6":                 `null`,
+		"f7":                 `null`,
+		"4401020304":         `"AQIDBA=="`,
+		"62225c":             `"\"\\"`,
+		"6449455446":         `"IETF"`,
+		"c074323031332d30332d32315432303a30343a30305a": `"2013-03-21T20:04:00Z"`,
+		"c11a514b67b0":               `1363896240`,
+		"83010203":                   `[1,2,3]`,
+		"a201020304":                 `{"1":2,"3":4}`,
+		"a26161016162820203":         `{"a":1,"b":[2,3]}`,
+		"5f42010243030405ff":         `"AQIDBAU="`,
+		"7f657374726561646d696e67ff": `"streaming"`,
+		"9f018202039f0405ffff":       `[1,[2,3],[4,5]]`,
+		"bf61610161629f0203ffff":     `{"a":1,"b":[2,3]}`,
+		"d9010643227822":             `"x"`,
+		// The invalid embedded JSON is written as a string.
+		"d90106437b2261": `"{\"a"`,
+	}
+	for in, expected := range cases {
+		bs, err := hexenc.DecodeString(in)
+		require.Nil(t, err)
+
+		var b bytes.Buffer
+		require.Nil(t, CBORToJSON(&b, bytes.NewReader(bs)), in)
+		require.Equal(t, expected+"\n", b.String(), in)
+	}
+
+	// Invalid input.
+	for _, in := range []string{"1903", "9f01", "bf6161", "ff", "1f", "bf"} {
+		bs, err := hexenc.DecodeString(in)
+		require.Nil(t, err)
+		require.NotNil(t, CBORToJSON(&bytes.Buffer{}, bytes.NewReader(bs)), in)
+	}
+	bs, _ := hexenc.DecodeString("1903")
+	require.Equal(t, io.ErrUnexpectedEOF, CBORToJSON(&bytes.Buffer{}, bytes.NewReader(bs)))
+
+	// The corrupted length of string does not allocate the declared size.
+	bs, _ = hexenc.DecodeString("5a7fffffff00")
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	require.Equal(t, io.ErrUnexpectedEOF, CBORToJSON(&bytes.Buffer{}, bytes.NewReader(bs)))
+	runtime.ReadMemStats(&after)
+	require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+
+	// Nesting depth.
+	deep := bytes.Repeat([]byte{0x81}, cborMaxDepth+2)
+	require.Equal(t, errCBORMaxDepth, CBORToJSON(&bytes.Buffer{}, bytes.NewReader(append(deep, 0x00))))
+}
+
+func TestCBOREncoder_Invalid(t *testing.T) {
+	enc := CBOREncoder()
+	enc.AddBeginMarker()
+	enc.AddString("string", "a\xffb")
+	enc.AddRawBytes("raw", []byte(`{"a":`))
+	enc.AddRawString("rawString", "\xff")
+	enc.AddRawBytes("json", []byte(`{"a":1}`))
+	enc.AddEndMarker()
+
+	var b bytes.Buffer
+	require.Nil(t, CBORToJSON(&b, bytes.NewReader(enc.Bytes())))
+	require.Equal(t, "{\"string\":\"a\ufffdb\",\"raw\":\"{\\\"a\\\":\",\"rawString\":\"\ufffd\",\"json\":{\"a\":1}}\n", b.String())
+	require.Nil(t, enc.Close())
+}
@@ -0,0 +1,488 @@
+package glog
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yu31/glog/pkg/buffer"
+)
+
+var (
+	_ Encoder      = (*consoleEncoder)(nil)
+	_ StackEncoder = (*consoleEncoder)(nil)
+)
+
+var _consoleBufferPool = buffer.NewPool()
+
+// consoleMsgWidth is the width that the message is padded to
+// if it's followed by other fields, so that the fields are aligned.
+const consoleMsgWidth = 40
+
+// ANSI escape codes used by consoleEncoder.
+const (
+	colorReset   = "\x1b[0m"
+	colorDim     = "\x1b[2m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorBoldRed = "\x1b[1;31m"
+)
+
+// ConsoleEncoder return a new encoder implements by consoleEncoder without the
+// colors; Use ConsoleEncoderFor to enable the colors by the output writer.
+//
+// The consoleEncoder is designed for human reading in local development,
+// the output format may be changed in future; Use TextEncoder, JSONEncoder or
+// LogfmtEncoder if the log needs to be parsed by programs.
+func ConsoleEncoder() Encoder { return newConsoleEncoder(false) }
+
+// ConsoleEncoderFunc returns an EncoderFunc that creates the consoleEncoder
+// with or without the colors.
+func ConsoleEncoderFunc(color bool) EncoderFunc {
+	return func() Encoder { return newConsoleEncoder(color) }
+}
+
+// ConsoleEncoderFor returns an EncoderFunc that creates the consoleEncoder
+// with the colors if ColorEnabled(w) returns true, w should be the writer
+// that the entries are written to, e.g. os.Stderr.
+func ConsoleEncoderFor(w io.Writer) EncoderFunc {
+	return ConsoleEncoderFunc(ColorEnabled(w))
+}
+
+// ColorEnabled reports whether the ANSI colors should be written to w.
+// It returns true only if w is an *os.File of character device (e.g. a terminal),
+// the environment variable NO_COLOR is not set (see https://no-color.org)
+// and TERM is not "dumb".
+func ColorEnabled(w io.Writer) bool {
+	if v, ok := os.LookupEnv("NO_COLOR"); ok && v != "" {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+func newConsoleEncoder(color bool) *consoleEncoder {
+	enc := &consoleEncoder{
+		buf:      _consoleBufferPool.Get(),
+		color:    color,
+		msgStart: -1,
+	}
+	return enc
+}
+
+type consoleEncoder struct {
+	buf   *buffer.Buffer
+	color bool
+
+	// header is the end offset of time and level, the message is placed after it.
+	header int
+	// msgStart is the start offset of the message that waiting to be padded, -1 if none.
+	msgStart int
+
+	// depth is the depth of the nested object or array being encoded.
+	depth int
+	// first indicates whether no element be added into the nested object or array.
+	first bool
+	// dimmed indicates whether the dim color is in effect.
+	dimmed bool
+}
+
+// Bytes Implements encoder
+func (enc *consoleEncoder) Bytes() []byte {
+	return enc.buf.Bytes()
+}
+func (enc *consoleEncoder) Close() error {
+	enc.buf.Free()
+	enc.buf = nil
+	return nil
+}
+
+// AddBeginMarker Implements BuildEncoder.
+func (enc *consoleEncoder) AddBeginMarker() {}
+func (enc *consoleEncoder) AddEndMarker()   { enc.resetColor() }
+func (enc *consoleEncoder) AddLineBreak()   { enc.resetColor(); enc.buf.AppendByte('\n') }
+func (enc *consoleEncoder) AddMsg(msg string) {
+	if msg == "" {
+		return
+	}
+	enc.resetColor()
+	start := enc.buf.Len()
+	if start > 0 {
+		enc.buf.AppendByte(' ')
+	}
+	AppendStringEscape(enc.buf, msg)
+	if start == enc.header {
+		// Nothing follows the message yet, pad it by the next field.
+		enc.msgStart = start
+		return
+	}
+	// The fields has been added, move the message in front of them.
+	enc.msgStart = start
+	enc.padMsg()
+	rotateBytes(enc.buf.Bytes()[enc.header:], start-enc.header)
+}
+func (enc *consoleEncoder) AddEntryTime(t time.Time, layout string) {
+	enc.appendElementSeparator()
+	enc.setColor(colorDim)
+	enc.appendTime(t, layout)
+	enc.setColor(colorReset)
+	enc.header = enc.buf.Len()
+}
+func (enc *consoleEncoder) AddLevel(level Level) {
+	enc.appendElementSeparator()
+	enc.setColor(consoleLevelColor(level))
+	enc.buf.AppendString(consoleLevelTag(level))
+	enc.setColor(colorReset)
+	enc.header = enc.buf.Len()
+}
+func (enc *consoleEncoder) AddCaller(frame runtime.Frame, layout int8) {
+	enc.appendElementSeparator()
+	enc.setColor(colorDim)
+	enc.buf.AppendByte('(')
+	AppendStringEscape(enc.buf, CallerFile(frame.File, layout))
+	enc.buf.AppendByte(':')
+	enc.buf.AppendInt(int64(frame.Line))
+	if frame.Function != "" {
+		enc.buf.AppendByte(' ')
+		AppendStringEscape(enc.buf, CallerFunc(frame.Function, layout))
+	}
+	enc.buf.AppendByte(')')
+	enc.setColor(colorReset)
+}
+func (enc *consoleEncoder) AddStack(pcs []uintptr) {
+	if len(pcs) == 0 {
+		return
+	}
+	// The stack is written as an indented block below the line.
+	enc.resetColor()
+	enc.setColor(colorDim)
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		enc.buf.AppendString("\n\t")
+		AppendStringEscape(enc.buf, frame.Function)
+		enc.buf.AppendString("()\n\t\t")
+		AppendStringEscape(enc.buf, frame.File)
+		enc.buf.AppendByte(':')
+		enc.buf.AppendInt(int64(frame.Line))
+		if !more {
+			break
+		}
+	}
+	enc.setColor(colorReset)
+}
+func (enc *consoleEncoder) WriteIn(p []byte) error {
+	if len(p) == 0 {
+		return nil
+	}
+	enc.appendElementSeparator()
+	_, err := enc.buf.Write(p)
+	// The fields written by another consoleEncoder may be left dimmed.
+	enc.dimmed = enc.color
+	return err
+}
+
+// AddByte Implements ObjectEncoder.
+func (enc *consoleEncoder) AddByte(k string, b byte)       { enc.appendKey(k); enc.appendByteInt(b) }
+func (enc *consoleEncoder) AddString(k string, s string)   { enc.appendKey(k); enc.appendString(s) }
+func (enc *consoleEncoder) AddBool(k string, v bool)       { enc.appendKey(k); enc.appendBool(v) }
+func (enc *consoleEncoder) AddInt64(k string, i int64)     { enc.appendKey(k); enc.appendInt64(i) }
+func (enc *consoleEncoder) AddUnt64(k string, i uint64)    { enc.appendKey(k); enc.appendUint64(i) }
+func (enc *consoleEncoder) AddFloat64(k string, f float64) { enc.appendKey(k); enc.appendFloat(f) }
+func (enc *consoleEncoder) AddComplex128(k string, c complex128) {
+	enc.appendKey(k)
+	enc.appendComplex128(c)
+}
+func (enc *consoleEncoder) AddRawBytes(k string, bs []byte) { enc.appendKey(k); enc.appendRawBytes(bs) }
+func (enc *consoleEncoder) AddRawString(k string, s string) { enc.appendKey(k); enc.appendRawString(s) }
+func (enc *consoleEncoder) AddTime(k string, t time.Time, layout string) {
+	enc.appendKey(k)
+	enc.appendTime(t, layout)
+}
+func (enc *consoleEncoder) AddDuration(k string, d time.Duration, layout int8) {
+	enc.appendKey(k)
+	enc.appendDuration(d, layout)
+}
+func (enc *consoleEncoder) AddArray(k string, am ArrayMarshaler) error {
+	enc.appendKey(k)
+	return enc.appendArray(am)
+}
+func (enc *consoleEncoder) AddObject(k string, om ObjectMarshaler) error {
+	enc.appendKey(k)
+	return enc.appendObject(om)
+}
+func (enc *consoleEncoder) AddInterface(k string, i interface{}) error {
+	enc.appendKey(k)
+	return enc.appendInterface(i)
+}
+
+// AppendByte Implements FieldEncoder.
+func (enc *consoleEncoder) AppendByte(v byte)       { enc.appendElementSeparator(); enc.appendByteInt(v) }
+func (enc *consoleEncoder) AppendString(s string)   { enc.appendElementSeparator(); enc.appendString(s) }
+func (enc *consoleEncoder) AppendBool(v bool)       { enc.appendElementSeparator(); enc.appendBool(v) }
+func (enc *consoleEncoder) AppendInt64(i int64)     { enc.appendElementSeparator(); enc.appendInt64(i) }
+func (enc *consoleEncoder) AppendUnt64(i uint64)    { enc.appendElementSeparator(); enc.appendUint64(i) }
+func (enc *consoleEncoder) AppendFloat64(f float64) { enc.appendElementSeparator(); enc.appendFloat(f) }
+func (enc *consoleEncoder) AppendComplex128(c complex128) {
+	enc.appendElementSeparator()
+	enc.appendComplex128(c)
+}
+func (enc *consoleEncoder) AppendRawBytes(bs []byte) {
+	enc.appendElementSeparator()
+	enc.appendRawBytes(bs)
+}
+func (enc *consoleEncoder) AppendRawString(s string) {
+	enc.appendElementSeparator()
+	enc.appendRawString(s)
+}
+func (enc *consoleEncoder) AppendDuration(d time.Duration, layout int8) {
+	enc.appendElementSeparator()
+	enc.appendDuration(d, layout)
+}
+func (enc *consoleEncoder) AppendTime(t time.Time, layout string) {
+	enc.appendElementSeparator()
+	enc.appendTime(t, layout)
+}
+func (enc *consoleEncoder) AppendArray(am ArrayMarshaler) error {
+	enc.appendElementSeparator()
+	return enc.appendArray(am)
+}
+func (enc *consoleEncoder) AppendObject(om ObjectMarshaler) error {
+	enc.appendElementSeparator()
+	return enc.appendObject(om)
+}
+func (enc *consoleEncoder) AppendInterface(i interface{}) error {
+	enc.appendElementSeparator()
+	return enc.appendInterface(i)
+}
+
+// Add k between ElementSeparator and FieldSeparator,
+// the top-level fields are dimmed until the next element.
+func (enc *consoleEncoder) appendKey(key string) {
+	enc.appendElementSeparator()
+	if enc.depth == 0 && enc.color {
+		enc.buf.AppendString(colorDim)
+		enc.dimmed = true
+	}
+	AppendStringEscape(enc.buf, key)
+	enc.buf.AppendByte('=')
+}
+
+// Add elements separator; the top-level elements are separated by a space,
+// and the elements in nested object or array are separated by a comma.
+func (enc *consoleEncoder) appendElementSeparator() {
+	if enc.depth > 0 {
+		if enc.first {
+			enc.first = false
+			return
+		}
+		enc.buf.AppendString(", ")
+		return
+	}
+	enc.resetColor()
+	if enc.msgStart >= 0 {
+		enc.padMsg()
+	}
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(' ')
+	}
+}
+
+// padMsg pads the message started at msgStart to consoleMsgWidth.
+func (enc *consoleEncoder) padMsg() {
+	n := utf8.RuneCount(enc.buf.Bytes()[enc.msgStart:])
+	for ; n < consoleMsgWidth; n++ {
+		enc.buf.AppendByte(' ')
+	}
+	enc.msgStart = -1
+}
+
+func (enc *consoleEncoder) setColor(color string) {
+	if enc.color {
+		enc.buf.AppendString(color)
+	}
+}
+
+func (enc *consoleEncoder) resetColor() {
+	if enc.dimmed {
+		enc.buf.AppendString(colorReset)
+		enc.dimmed = false
+	}
+}
+
+func (enc *consoleEncoder) appendString(s string) {
+	if !logfmtNeedsQuote(s) {
+		enc.buf.AppendString(s)
+		return
+	}
+	enc.buf.AppendByte('"')
+	AppendStringEscape(enc.buf, s)
+	enc.buf.AppendByte('"')
+}
+
+func (enc *consoleEncoder) appendByteInt(b byte) {
+	enc.buf.AppendUint(uint64(b))
+}
+
+func (enc *consoleEncoder) appendRawBytes(bs []byte) {
+	_, _ = enc.buf.Write(bs)
+}
+
+func (enc *consoleEncoder) appendRawString(s string) {
+	enc.buf.AppendString(s)
+}
+
+func (enc *consoleEncoder) appendInt64(i int64) {
+	enc.buf.AppendInt(i)
+}
+
+func (enc *consoleEncoder) appendUint64(i uint64) {
+	enc.buf.AppendUint(i)
+}
+
+func (enc *consoleEncoder) appendBool(v bool) {
+	enc.buf.AppendBool(v)
+}
+
+func (enc *consoleEncoder) appendTime(t time.Time, layout string) {
+	switch layout {
+	case TimeFormatUnixSecond:
+		enc.buf.AppendInt(t.Unix())
+	case TimeFormatUnixMilli:
+		enc.buf.AppendInt(t.UnixNano() / 1e6)
+	case TimeFormatUnixMicro:
+		enc.buf.AppendInt(t.UnixNano() / 1e3)
+	case TimeFormatUnixNano:
+		enc.buf.AppendInt(t.UnixNano())
+	default:
+		enc.buf.AppendTime(t, layout)
+	}
+}
+
+func (enc *consoleEncoder) appendDuration(d time.Duration, layout int8) {
+	AppendDuration(enc.buf, d, layout)
+}
+
+func (enc *consoleEncoder) appendFloat(f float64) {
+	switch {
+	case math.IsNaN(f):
+		enc.buf.AppendString(`NaN`)
+	case math.IsInf(f, 1):
+		enc.buf.AppendString(`+Inf`)
+	case math.IsInf(f, -1):
+		enc.buf.AppendString(`-Inf`)
+	default:
+		enc.buf.AppendFloat(f, 64)
+	}
+}
+
+func (enc *consoleEncoder) appendComplex128(c complex128) {
+	// Cast to a platform-independent, fixed-size type.
+	r, i := float64(real(c)), float64(imag(c))
+	appendComplex(enc.buf, r, i)
+}
+
+func (enc *consoleEncoder) appendArray(am ArrayMarshaler) error {
+	enc.buf.AppendByte('[')
+	enc.depth++
+	enc.first = true
+	err := am.MarshalGLogArray(enc)
+	enc.depth--
+	enc.first = false
+	enc.buf.AppendByte(']')
+	return err
+}
+
+func (enc *consoleEncoder) appendObject(om ObjectMarshaler) error {
+	enc.buf.AppendByte('{')
+	enc.depth++
+	enc.first = true
+	err := om.MarshalGLogObject(enc)
+	enc.depth--
+	enc.first = false
+	enc.buf.AppendByte('}')
+	return err
+}
+
+func (enc *consoleEncoder) appendInterface(i interface{}) error {
+	switch i.(type) {
+	case nil:
+		enc.buf.AppendString("<nil>")
+		return nil
+	default:
+		enc.appendString(fmt.Sprintf("%+v", i))
+	}
+	return nil
+}
+
+// consoleLevelTag returns the fixed width tag of level.
+func consoleLevelTag(level Level) string {
+	switch level {
+	case TraceLevel:
+		return "TRC"
+	case DebugLevel:
+		return "DBG"
+	case InfoLevel:
+		return "INF"
+	case WarnLevel:
+		return "WRN"
+	case ErrorLevel:
+		return "ERR"
+	case FatalLevel:
+		return "FTL"
+	case PanicLevel:
+		return "PNC"
+	default:
+		return "???"
+	}
+}
+
+// consoleLevelColor returns the ANSI color of level.
+func consoleLevelColor(level Level) string {
+	switch level {
+	case TraceLevel:
+		return colorMagenta
+	case DebugLevel:
+		return colorBlue
+	case InfoLevel:
+		return colorGreen
+	case WarnLevel:
+		return colorYellow
+	case ErrorLevel:
+		return colorRed
+	case FatalLevel, PanicLevel:
+		return colorBoldRed
+	default:
+		return colorReset
+	}
+}
+
+// rotateBytes moves bs[mid:] to the front of bs in place.
+func rotateBytes(bs []byte, mid int) {
+	reverseBytes(bs[:mid])
+	reverseBytes(bs[mid:])
+	reverseBytes(bs)
+}
+
+func reverseBytes(bs []byte) {
+	for i, j := 0, len(bs)-1; i < j; i, j = i+1, j-1 {
+		bs[i], bs[j] = bs[j], bs[i]
+	}
+}
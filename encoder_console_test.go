@@ -0,0 +1,122 @@
+package glog
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleEncoder_AddObject(t *testing.T) {
+	enc := ConsoleEncoderFunc(false)()
+	defer func() {
+		_ = enc.Close()
+	}()
+
+	tm := time.Date(2020, 11, 4, 18, 6, 13, 0, time.UTC)
+	var infos infos
+	infos = append(infos, &info{
+		Name:  "aa",
+		Sex:   "man",
+		Age:   999,
+		Times: timeArray{tm, tm},
+	})
+	infos = append(infos, &info{
+		Name:  "b b",
+		Sex:   "man",
+		Age:   999,
+		Times: timeArray{},
+	})
+
+	enc.AddBeginMarker()
+	require.Nil(t, enc.AddArray("infos", infos))
+	require.Nil(t, enc.AddObject("info", infos[0]))
+	enc.AddEndMarker()
+
+	require.Equal(t, `infos=[{name=aa, sex=man, age=999, times=[2020-11-04T18:06:13Z, 2020-11-04T18:06:13Z]}, `+
+		`{name="b b", sex=man, age=999, times=[]}] `+
+		`info={name=aa, sex=man, age=999, times=[2020-11-04T18:06:13Z, 2020-11-04T18:06:13Z]}`, string(enc.Bytes()))
+}
+
+func TestConsoleEncoder_Align(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithEncoderFunc(ConsoleEncoderFunc(false)).WithExporter(StandardExporter(&b)).
+		WithTimeLayout("15:04:05")
+	l.WithFields().AddString("rid", "x1")
+
+	l.Info().Msg("short").String("k", "v").Fire()
+	l.Warn().String("k", "v").Msg("a bit longer message").Fire()
+	l.Error().Msg("no fields").Fire()
+	l.WithLevel(TraceLevel)
+	l.Trace().Fire()
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	require.Equal(t, 4, len(lines))
+
+	require.Equal(t, " INF short"+strings.Repeat(" ", 34)+" k=v rid=x1", lines[0][8:])
+	require.Equal(t, " WRN a bit longer message"+strings.Repeat(" ", 19)+" k=v rid=x1", lines[1][8:])
+	require.Equal(t, " ERR no fields"+strings.Repeat(" ", 30)+" rid=x1", lines[2][8:])
+	require.Equal(t, " TRC rid=x1", lines[3][8:])
+	require.Equal(t, strings.Index(lines[0], "k=v"), strings.Index(lines[1], "k=v"))
+
+	// The message is not padded if nothing follows it.
+	b.Reset()
+	l.ResetFields()
+	l.Info().Msg("alone").Fire()
+	require.True(t, strings.HasSuffix(b.String(), " INF alone\n"))
+}
+
+func TestConsoleEncoder_Color(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithEncoderFunc(ConsoleEncoderFunc(true)).WithExporter(StandardExporter(&b)).
+		WithTimeLayout("15:04:05")
+	l.WithFields().AddString("rid", "x1")
+
+	l.Error().String("k", "v").Msg("hello").Fire()
+	s := b.String()
+
+	require.Contains(t, s, colorRed+"ERR"+colorReset)
+	require.Contains(t, s, " hello"+strings.Repeat(" ", 34)+" "+colorDim+"k=v"+colorReset+" "+colorDim+"rid=x1"+colorReset+"\n")
+	require.True(t, strings.HasPrefix(s, colorDim))
+}
+
+func TestColorEnabled(t *testing.T) {
+	var b bytes.Buffer
+	require.False(t, ColorEnabled(&b))
+
+	f, err := os.Open(os.DevNull)
+	require.Nil(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+
+	// The NO_COLOR disables the color even if the file is a character device.
+	old, ok := os.LookupEnv("NO_COLOR")
+	defer func() {
+		if ok {
+			_ = os.Setenv("NO_COLOR", old)
+		} else {
+			_ = os.Unsetenv("NO_COLOR")
+		}
+	}()
+	require.Nil(t, os.Setenv("NO_COLOR", "1"))
+	require.False(t, ColorEnabled(f))
+}
+
+func TestConsoleEncoderFor(t *testing.T) {
+	// The colors are disabled by default and for the writer that is not a terminal.
+	for _, f := range []EncoderFunc{ConsoleEncoder, ConsoleEncoderFor(&bytes.Buffer{})} {
+		enc := f()
+		enc.AddLevel(InfoLevel)
+		require.NotContains(t, string(enc.Bytes()), "\x1b[")
+		require.Nil(t, enc.Close())
+	}
+
+	enc := ConsoleEncoderFunc(true)()
+	enc.AddLevel(InfoLevel)
+	require.Contains(t, string(enc.Bytes()), "\x1b[")
+	require.Nil(t, enc.Close())
+}
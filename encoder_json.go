@@ -10,22 +10,36 @@ import (
 	"github.com/yu31/glog/pkg/buffer"
 )
 
-var _ Encoder = (*jsonEncoder)(nil)
+var (
+	_ Encoder      = (*jsonEncoder)(nil)
+	_ StackEncoder = (*jsonEncoder)(nil)
+)
 
 var _jsonBufferPool = buffer.NewPool()
 
 // JSONEncoder return a new encoder implements by jsonEncoder.
-func JSONEncoder() Encoder { return newJSONEncoder() }
+func JSONEncoder() Encoder { return newJSONEncoder(&defaultJSONEncoderConfig) }
 
-func newJSONEncoder() *jsonEncoder {
+func newJSONEncoder(cfg *JSONEncoderConfig) *jsonEncoder {
 	enc := &jsonEncoder{
 		buf: _jsonBufferPool.Get(),
+		cfg: cfg,
+	}
+	if cfg.FieldsKey != "" {
+		enc.head = _jsonBufferPool.Get()
 	}
 	return enc
 }
 
 type jsonEncoder struct {
 	buf *buffer.Buffer
+	// cfg is shared by the encoders created by the same EncoderFunc, it must not be modified.
+	cfg *JSONEncoderConfig
+
+	// head is used only if the cfg.FieldsKey is set; it holds the begin marker,
+	// entry time, level and message while the buf holds the other fields,
+	// and they are merged by AddEndMarker.
+	head *buffer.Buffer
 }
 
 // Bytes Implements encoder.
@@ -35,39 +49,113 @@ func (enc *jsonEncoder) Bytes() []byte {
 func (enc *jsonEncoder) Close() error {
 	enc.buf.Free()
 	enc.buf = nil
+	if enc.head != nil {
+		enc.head.Free()
+		enc.head = nil
+	}
 	return nil
 }
 
 // AddBeginMarker Implements BuildEncoder.
-func (enc *jsonEncoder) AddBeginMarker() { enc.buf.AppendByte('{') }
-func (enc *jsonEncoder) AddEndMarker()   { enc.buf.AppendByte('}') }
-func (enc *jsonEncoder) AddLineBreak()   { enc.buf.AppendByte('\n') }
+func (enc *jsonEncoder) AddBeginMarker() {
+	enc.swapHead()
+	enc.buf.AppendByte('{')
+	enc.buf.AppendString(enc.cfg.StaticFields)
+	enc.swapHead()
+}
+func (enc *jsonEncoder) AddEndMarker() {
+	if enc.head != nil {
+		fields := enc.buf
+		enc.buf, enc.head = enc.head, nil
+		if fields.Len() > 0 {
+			enc.appendKey(enc.cfg.FieldsKey)
+			enc.buf.AppendByte('{')
+			_, _ = enc.buf.Write(fields.Bytes())
+			enc.buf.AppendByte('}')
+		}
+		fields.Free()
+	}
+	enc.buf.AppendByte('}')
+}
+func (enc *jsonEncoder) AddLineBreak() { enc.buf.AppendByte('\n') }
 func (enc *jsonEncoder) AddMsg(msg string) {
-	enc.appendKey("message")
+	enc.swapHead()
+	enc.appendKey(enc.cfg.MessageKey)
 	enc.appendString(msg)
+	enc.swapHead()
 }
 func (enc *jsonEncoder) AddEntryTime(t time.Time, layout string) {
-	enc.appendKey("time")
+	if enc.cfg.TimeLayout != "" {
+		layout = enc.cfg.TimeLayout
+	}
+	enc.swapHead()
+	enc.appendKey(enc.cfg.TimeKey)
 	enc.appendTime(t, layout)
+	enc.swapHead()
 }
 func (enc *jsonEncoder) AddLevel(level Level) {
-	enc.appendKey("level")
-	enc.appendString(level.String())
+	enc.swapHead()
+	enc.appendLevel(level)
+	enc.swapHead()
 }
-func (enc *jsonEncoder) AddCaller(skip int) {
-	_, file, line, ok := runtime.Caller(skip + 1)
-	if !ok {
+func (enc *jsonEncoder) appendLevel(level Level) {
+	if enc.cfg.EncodeLevel != nil {
+		enc.cfg.EncodeLevel(level, enc)
 		return
 	}
-	enc.appendKey("caller")
-	enc.appendElementSeparator()
+	enc.appendKey(enc.cfg.LevelKey)
+	switch enc.cfg.LevelFormat {
+	case LevelFormatUpper:
+		enc.appendString(level.CapitalString())
+	case LevelFormatNumeric:
+		enc.appendInt64(int64(level))
+	default:
+		enc.appendString(level.String())
+	}
+}
+func (enc *jsonEncoder) AddCaller(frame runtime.Frame, layout int8) {
+	if enc.cfg.EncodeCaller != nil {
+		enc.cfg.EncodeCaller(frame, layout, enc)
+		return
+	}
+	enc.appendKey(enc.cfg.CallerKey)
 	enc.buf.AppendByte('"')
-	AppendStringEscape(enc.buf, file)
+	AppendStringEscape(enc.buf, CallerFile(frame.File, layout))
 	enc.buf.AppendByte(':')
-	enc.buf.AppendInt(int64(line))
+	enc.buf.AppendInt(int64(frame.Line))
 	enc.buf.AppendByte('"')
-
-	//enc.appendString(file + ":" + strconv.Itoa(line))
+	if frame.Function != "" {
+		enc.appendKey(enc.cfg.FunctionKey)
+		enc.appendString(CallerFunc(frame.Function, layout))
+	}
+}
+func (enc *jsonEncoder) AddStack(pcs []uintptr) {
+	if len(pcs) == 0 {
+		return
+	}
+	if enc.cfg.EncodeStack != nil {
+		enc.cfg.EncodeStack(pcs, enc)
+		return
+	}
+	enc.appendKey(enc.cfg.StackKey)
+	enc.buf.AppendByte('[')
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		enc.appendElementSeparator()
+		enc.buf.AppendByte('{')
+		enc.appendKey("func")
+		enc.appendString(frame.Function)
+		enc.appendKey("file")
+		enc.appendString(frame.File)
+		enc.appendKey("line")
+		enc.appendInt64(int64(frame.Line))
+		enc.buf.AppendByte('}')
+		if !more {
+			break
+		}
+	}
+	enc.buf.AppendByte(']')
 }
 func (enc *jsonEncoder) WriteIn(p []byte) error {
 	if len(p) == 0 {
@@ -152,6 +240,14 @@ func (enc *jsonEncoder) AppendInterface(i interface{}) error {
 	return enc.appendInterface(i)
 }
 
+// swapHead swaps the buf and head if the head is in use, it's called in pairs
+// around the encoding of the fields that belong to the head.
+func (enc *jsonEncoder) swapHead() {
+	if enc.head != nil {
+		enc.buf, enc.head = enc.head, enc.buf
+	}
+}
+
 // Add k between ElementSeparator and FieldSeparator.
 func (enc *jsonEncoder) appendKey(key string) {
 	enc.appendElementSeparator()
@@ -251,10 +347,7 @@ func (enc *jsonEncoder) appendComplex128(c complex128) {
 	enc.buf.AppendByte('"')
 	// Because we're always in a quoted string, we can use strconv without
 	// special-casing NaN and +/-Inf.
-	enc.buf.AppendFloat(r, 64)
-	enc.buf.AppendByte('+')
-	enc.buf.AppendFloat(i, 64)
-	enc.buf.AppendByte('i')
+	appendComplex(enc.buf, r, i)
 	enc.buf.AppendByte('"')
 }
 
@@ -266,6 +359,10 @@ func (enc *jsonEncoder) appendArray(am ArrayMarshaler) error {
 }
 
 func (enc *jsonEncoder) appendObject(om ObjectMarshaler) error {
+	if eo, ok := om.(errorObject); ok && (enc.cfg.ErrorMessageKey != "" || enc.cfg.ErrorTypeKey != "") {
+		eo.msgKey, eo.typeKey = enc.cfg.ErrorMessageKey, enc.cfg.ErrorTypeKey
+		om = eo
+	}
 	enc.buf.AppendByte('{')
 	err := om.MarshalGLogObject(enc)
 	enc.buf.AppendByte('}')
@@ -0,0 +1,100 @@
+package glog
+
+import "runtime"
+
+// Defines the format type for level.
+const (
+	// LevelFormatLower formats the level with lower-case name, e.g. "info".
+	LevelFormatLower int8 = iota
+	// LevelFormatUpper formats the level with upper-case name, e.g. "INFO".
+	LevelFormatUpper
+	// LevelFormatNumeric formats the level with its numeric value, e.g. 2.
+	LevelFormatNumeric
+)
+
+// JSONEncoderConfig declares the options for the jsonEncoder.
+// The empty key uses the default key name.
+type JSONEncoderConfig struct {
+	// MessageKey is the key of message, defaults to "message".
+	MessageKey string
+	// TimeKey is the key of entry time, defaults to "time".
+	TimeKey string
+	// LevelKey is the key of level, defaults to "level".
+	LevelKey string
+	// CallerKey is the key of caller, defaults to "caller".
+	CallerKey string
+	// FunctionKey is the key of caller's function name, defaults to "func".
+	FunctionKey string
+	// StackKey is the key of stack trace, defaults to "stack".
+	StackKey string
+
+	// LevelFormat set the format of level, defaults to LevelFormatLower.
+	LevelFormat int8
+
+	// TimeLayout is the layout of entry time; the logger's timeLayout is used if empty.
+	TimeLayout string
+
+	// EncodeLevel used to encode the level with its own keys if not nil;
+	// the LevelKey and LevelFormat are ignored.
+	EncodeLevel func(level Level, enc ObjectEncoder)
+
+	// EncodeCaller used to encode the caller with its own keys if not nil;
+	// the CallerKey and FunctionKey are ignored. The frame.Function is
+	// empty if the logger disables the function name in caller.
+	EncodeCaller func(frame runtime.Frame, layout int8, enc ObjectEncoder)
+
+	// EncodeStack used to encode the stack trace with its own keys if not nil;
+	// the StackKey is ignored.
+	EncodeStack func(pcs []uintptr, enc ObjectEncoder)
+
+	// ErrorMessageKey and ErrorTypeKey are the keys of message and concrete
+	// type in the object encoded by Entry.Error, default to "msg" and "type".
+	ErrorMessageKey string
+	ErrorTypeKey    string
+
+	// FieldsKey is the key of an object that holds all fields except
+	// the entry time, level and message if not empty.
+	FieldsKey string
+
+	// StaticFields is the already encoded JSON fields that added at
+	// the beginning of every entry if not empty, e.g. `"ecs.version":"1.6.0"`.
+	StaticFields string
+}
+
+var defaultJSONEncoderConfig = JSONEncoderConfig{
+	MessageKey:  "message",
+	TimeKey:     "time",
+	LevelKey:    "level",
+	CallerKey:   "caller",
+	FunctionKey: "func",
+	StackKey:    "stack",
+	LevelFormat: LevelFormatLower,
+}
+
+// JSONEncoderFunc returns an EncoderFunc that creates the jsonEncoder with the cfg.
+// The cfg can be nil, which means use the default options, the same as JSONEncoder.
+func JSONEncoderFunc(cfg *JSONEncoderConfig) EncoderFunc {
+	c := defaultJSONEncoderConfig
+	if cfg != nil {
+		c = *cfg
+		if c.MessageKey == "" {
+			c.MessageKey = defaultJSONEncoderConfig.MessageKey
+		}
+		if c.TimeKey == "" {
+			c.TimeKey = defaultJSONEncoderConfig.TimeKey
+		}
+		if c.LevelKey == "" {
+			c.LevelKey = defaultJSONEncoderConfig.LevelKey
+		}
+		if c.CallerKey == "" {
+			c.CallerKey = defaultJSONEncoderConfig.CallerKey
+		}
+		if c.FunctionKey == "" {
+			c.FunctionKey = defaultJSONEncoderConfig.FunctionKey
+		}
+		if c.StackKey == "" {
+			c.StackKey = defaultJSONEncoderConfig.StackKey
+		}
+	}
+	return func() Encoder { return newJSONEncoder(&c) }
+}
@@ -0,0 +1,242 @@
+package glog
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ecsVersion is the version of Elastic Common Schema that ECSEncoder conforms to.
+const ecsVersion = "1.6.0"
+
+var (
+	ecsEncoderConfig  = ECSEncoderConfig()
+	gcpEncoderConfig  = GCPEncoderConfig()
+	otelEncoderConfig = OTelEncoderConfig()
+)
+
+// ECSEncoder return a new jsonEncoder with the ECSEncoderConfig.
+func ECSEncoder() Encoder { return newJSONEncoder(ecsEncoderConfig) }
+
+// GCPEncoder return a new jsonEncoder with the GCPEncoderConfig.
+func GCPEncoder() Encoder { return newJSONEncoder(gcpEncoderConfig) }
+
+// OTelEncoder return a new jsonEncoder with the OTelEncoderConfig.
+func OTelEncoder() Encoder { return newJSONEncoder(otelEncoderConfig) }
+
+// ECSEncoderConfig returns the JSONEncoderConfig that conforms to the
+// Elastic Common Schema (ECS), see https://www.elastic.co/guide/en/ecs-logging/overview/current/intro.html.
+//
+// The error added by Entry.Error with key "error" is encoded as the ECS error
+// fields "error.message" and "error.type", and the stack trace is encoded as
+// "error.stack_trace" in plain text.
+//
+// e.g. {"ecs.version":"1.6.0","@timestamp":"2020-11-04T10:06:13.151Z","log.level":"error","message":"hello",
+// "error":{"message":"EOF","type":"*errors.errorString"},
+// "log.origin":{"file":{"name":"main.go","line":12},"function":"main.main"},"error.stack_trace":"main.main\n\t..."}
+func ECSEncoderConfig() *JSONEncoderConfig {
+	return &JSONEncoderConfig{
+		MessageKey:      "message",
+		TimeKey:         "@timestamp",
+		LevelKey:        "log.level",
+		CallerKey:       "log.origin",
+		FunctionKey:     "function",
+		StackKey:        "error.stack_trace",
+		LevelFormat:     LevelFormatLower,
+		TimeLayout:      "2006-01-02T15:04:05.000Z07:00",
+		EncodeCaller:    encodeECSCaller,
+		EncodeStack:     encodeECSStack,
+		ErrorMessageKey: "message",
+		ErrorTypeKey:    "type",
+		StaticFields:    `"ecs.version":"` + ecsVersion + `"`,
+	}
+}
+
+// GCPEncoderConfig returns the JSONEncoderConfig that conforms to the structured
+// logging of Google Cloud Logging, see https://cloud.google.com/logging/docs/structured-logging.
+//
+// e.g. {"time":"2020-11-04T10:06:13.151354Z","severity":"INFO","message":"hello",
+// "logging.googleapis.com/sourceLocation":{"file":"main.go","line":"12","function":"main.main"}}
+func GCPEncoderConfig() *JSONEncoderConfig {
+	return &JSONEncoderConfig{
+		MessageKey:   "message",
+		TimeKey:      "time",
+		LevelKey:     "severity",
+		CallerKey:    "logging.googleapis.com/sourceLocation",
+		FunctionKey:  "function",
+		StackKey:     "stack",
+		TimeLayout:   "2006-01-02T15:04:05.999999999Z07:00",
+		EncodeLevel:  encodeGCPLevel,
+		EncodeCaller: encodeGCPCaller,
+	}
+}
+
+// OTelEncoderConfig returns the JSONEncoderConfig that conforms to the
+// OpenTelemetry log data model, see https://opentelemetry.io/docs/specs/otel/logs/data-model.
+// All fields except the time, severity and body are put in the Attributes,
+// and the stack trace is encoded as "exception.stacktrace" in plain text.
+//
+// e.g. {"Timestamp":1604484373151354000,"SeverityText":"INFO","SeverityNumber":9,"Body":"hello",
+// "Attributes":{"code.filepath":"main.go","code.lineno":12,"code.function":"main.main"}}
+func OTelEncoderConfig() *JSONEncoderConfig {
+	return &JSONEncoderConfig{
+		MessageKey:   "Body",
+		TimeKey:      "Timestamp",
+		LevelKey:     "SeverityText",
+		CallerKey:    "code.filepath",
+		FunctionKey:  "code.function",
+		StackKey:     "exception.stacktrace",
+		TimeLayout:   TimeFormatUnixNano,
+		EncodeLevel:  encodeOTelLevel,
+		EncodeCaller: encodeOTelCaller,
+		EncodeStack:  encodeOTelStack,
+		FieldsKey:    "Attributes",
+	}
+}
+
+// ecsOrigin encodes the caller as ECS "log.origin".
+type ecsOrigin struct {
+	file     string
+	line     int
+	function string
+}
+
+func (o *ecsOrigin) MarshalGLogObject(enc ObjectEncoder) error {
+	if err := enc.AddObject("file", (*ecsOriginFile)(o)); err != nil {
+		return err
+	}
+	if o.function != "" {
+		enc.AddString("function", o.function)
+	}
+	return nil
+}
+
+type ecsOriginFile ecsOrigin
+
+func (o *ecsOriginFile) MarshalGLogObject(enc ObjectEncoder) error {
+	enc.AddString("name", o.file)
+	enc.AddInt64("line", int64(o.line))
+	return nil
+}
+
+func encodeECSCaller(frame runtime.Frame, layout int8, enc ObjectEncoder) {
+	_ = enc.AddObject("log.origin", &ecsOrigin{
+		file:     CallerFile(frame.File, layout),
+		line:     frame.Line,
+		function: CallerFunc(frame.Function, layout),
+	})
+}
+
+func encodeECSStack(pcs []uintptr, enc ObjectEncoder) {
+	enc.AddString("error.stack_trace", stackTrace(pcs))
+}
+
+// stackTrace formats the stack trace in plain text as the goroutine trace
+// printed by Go runtime, e.g. "main.main\n\t/src/app/main.go:12\n".
+func stackTrace(pcs []uintptr) string {
+	var sb strings.Builder
+	var arr [20]byte
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		sb.WriteString(frame.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(frame.File)
+		sb.WriteByte(':')
+		sb.Write(strconv.AppendInt(arr[:0], int64(frame.Line), 10))
+		sb.WriteByte('\n')
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// gcpSourceLocation encodes the caller as LogEntrySourceLocation of Google Cloud Logging.
+type gcpSourceLocation ecsOrigin
+
+func (o *gcpSourceLocation) MarshalGLogObject(enc ObjectEncoder) error {
+	enc.AddString("file", o.file)
+	// The line is int64 that encoded as string in the JSON of protobuf.
+	var arr [20]byte
+	enc.AddString("line", string(strconv.AppendInt(arr[:0], int64(o.line), 10)))
+	if o.function != "" {
+		enc.AddString("function", o.function)
+	}
+	return nil
+}
+
+func encodeGCPCaller(frame runtime.Frame, layout int8, enc ObjectEncoder) {
+	_ = enc.AddObject("logging.googleapis.com/sourceLocation", &gcpSourceLocation{
+		file:     CallerFile(frame.File, layout),
+		line:     frame.Line,
+		function: CallerFunc(frame.Function, layout),
+	})
+}
+
+// gcpSeverity returns the LogSeverity of Google Cloud Logging.
+func gcpSeverity(level Level) string {
+	switch level {
+	case TraceLevel, DebugLevel:
+		return "DEBUG"
+	case InfoLevel:
+		return "INFO"
+	case WarnLevel:
+		return "WARNING"
+	case ErrorLevel:
+		return "ERROR"
+	case FatalLevel:
+		return "CRITICAL"
+	case PanicLevel:
+		return "ALERT"
+	default:
+		return "DEFAULT"
+	}
+}
+
+func encodeGCPLevel(level Level, enc ObjectEncoder) {
+	enc.AddString("severity", gcpSeverity(level))
+}
+
+// otelSeverity returns the SeverityText and SeverityNumber of OpenTelemetry.
+func otelSeverity(level Level) (string, int64) {
+	switch level {
+	case TraceLevel:
+		return "TRACE", 1
+	case DebugLevel:
+		return "DEBUG", 5
+	case InfoLevel:
+		return "INFO", 9
+	case WarnLevel:
+		return "WARN", 13
+	case ErrorLevel:
+		return "ERROR", 17
+	case FatalLevel:
+		return "FATAL", 21
+	case PanicLevel:
+		return "FATAL2", 22
+	default:
+		// SEVERITY_NUMBER_UNSPECIFIED.
+		return "", 0
+	}
+}
+
+func encodeOTelLevel(level Level, enc ObjectEncoder) {
+	text, number := otelSeverity(level)
+	if text != "" {
+		enc.AddString("SeverityText", text)
+	}
+	enc.AddInt64("SeverityNumber", number)
+}
+
+func encodeOTelStack(pcs []uintptr, enc ObjectEncoder) {
+	enc.AddString("exception.stacktrace", stackTrace(pcs))
+}
+
+func encodeOTelCaller(frame runtime.Frame, layout int8, enc ObjectEncoder) {
+	enc.AddString("code.filepath", CallerFile(frame.File, layout))
+	enc.AddInt64("code.lineno", int64(frame.Line))
+	if frame.Function != "" {
+		enc.AddString("code.function", CallerFunc(frame.Function, layout))
+	}
+}
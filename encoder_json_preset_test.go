@@ -0,0 +1,156 @@
+package glog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// encodeGoldenEntries encodes the entries in the same order as Entry but with a fixed time and caller.
+func encodeGoldenEntries(t *testing.T, f EncoderFunc) []byte {
+	tm := time.Date(2020, 11, 4, 10, 6, 13, 151354000, time.UTC)
+	frame := runtime.Frame{File: "/src/app/main.go", Line: 12, Function: "github.com/app/server.(*Server).Start"}
+
+	// The fixed fields are encoded by another encoder.
+	fe := f()
+	fe.AddString("service", "api")
+	fields := append([]byte(nil), fe.Bytes()...)
+	require.Nil(t, fe.Close())
+
+	var b bytes.Buffer
+	levels := []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel}
+	for i, level := range levels {
+		enc := f()
+		enc.AddBeginMarker()
+		enc.AddEntryTime(tm, time.RFC3339)
+		enc.AddLevel(level)
+		enc.AddInt64("attempt", int64(i))
+		if level == ErrorLevel {
+			require.Nil(t, enc.AddObject("error", errorObject{err: errors.New("connection refused")}))
+		}
+		enc.AddMsg("request " + level.String())
+		require.Nil(t, enc.AddArray("tags", stringArray{"a", "b"}))
+		require.Nil(t, enc.WriteIn(fields))
+		if level >= WarnLevel {
+			enc.AddCaller(frame, CallerFormatShort)
+		}
+		enc.AddEndMarker()
+		enc.AddLineBreak()
+		b.Write(enc.Bytes())
+		require.Nil(t, enc.Close())
+	}
+	return b.Bytes()
+}
+
+func TestJSONEncoder_Presets(t *testing.T) {
+	presets := map[string]EncoderFunc{
+		"ecs":  ECSEncoder,
+		"gcp":  GCPEncoder,
+		"otel": OTelEncoder,
+	}
+	for name, f := range presets {
+		t.Run(name, func(t *testing.T) {
+			got := encodeGoldenEntries(t, f)
+			for _, line := range bytes.Split(bytes.TrimSpace(got), []byte("\n")) {
+				require.True(t, json.Valid(line), string(line))
+			}
+
+			golden := filepath.Join("testdata", name+".golden")
+			if *updateGolden {
+				require.Nil(t, ioutil.WriteFile(golden, got, 0644))
+			}
+			want, err := ioutil.ReadFile(golden)
+			require.Nil(t, err)
+			require.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestJSONEncoder_PresetsWithLogger(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithEncoderFunc(OTelEncoder).WithExporter(StandardExporter(&b)).
+		WithCaller(true).WithCallerFunc(true)
+	l.WithFields().AddString("service", "api")
+
+	l.Info().Msg("hello").Int("code", 200).Fire()
+
+	var m struct {
+		Timestamp      int64
+		SeverityText   string
+		SeverityNumber int
+		Body           string
+		Attributes     map[string]interface{}
+	}
+	require.Nil(t, json.Unmarshal(b.Bytes(), &m), b.String())
+	require.Greater(t, m.Timestamp, int64(0))
+	require.Equal(t, "INFO", m.SeverityText)
+	require.Equal(t, 9, m.SeverityNumber)
+	require.Equal(t, "hello", m.Body)
+	require.Equal(t, "api", m.Attributes["service"])
+	require.Equal(t, float64(200), m.Attributes["code"])
+	require.Contains(t, m.Attributes["code.filepath"], "encoder_json_preset_test.go")
+	require.Equal(t, "github.com/yu31/glog.TestJSONEncoder_PresetsWithLogger", m.Attributes["code.function"])
+}
+
+// flattenJSON flattens the nested objects into the dotted keys as the ECS does.
+func flattenJSON(prefix string, m map[string]interface{}, out map[string]interface{}) {
+	for k, v := range m {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]interface{}); ok {
+			flattenJSON(k, sub, out)
+			continue
+		}
+		out[k] = v
+	}
+}
+
+func TestJSONEncoder_ECSFields(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithEncoderFunc(ECSEncoder).WithExporter(StandardExporter(&b)).
+		WithCaller(true).WithCallerFunc(true)
+
+	l.Error().Msg("hello").Error("error", errors.New("connection refused")).Stack().Fire()
+
+	var m map[string]interface{}
+	require.Nil(t, json.Unmarshal(b.Bytes(), &m), b.String())
+	fields := make(map[string]interface{})
+	flattenJSON("", m, fields)
+
+	// The field names are defined by https://www.elastic.co/guide/en/ecs/current/ecs-field-reference.html.
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	require.ElementsMatch(t, []string{
+		"ecs.version", "@timestamp", "log.level", "message",
+		"error.message", "error.type", "error.stack_trace",
+		"log.origin.file.name", "log.origin.file.line", "log.origin.function",
+	}, keys)
+
+	require.Equal(t, "1.6.0", fields["ecs.version"])
+	require.Equal(t, "error", fields["log.level"])
+	require.Equal(t, "hello", fields["message"])
+	require.Equal(t, "connection refused", fields["error.message"])
+	require.Equal(t, "*errors.errorString", fields["error.type"])
+	require.Contains(t, fields["log.origin.file.name"], "encoder_json_preset_test.go")
+	require.Equal(t, "github.com/yu31/glog.TestJSONEncoder_ECSFields", fields["log.origin.function"])
+
+	// The stack trace is a plain text.
+	stack, ok := fields["error.stack_trace"].(string)
+	require.True(t, ok, fields["error.stack_trace"])
+	require.True(t, strings.HasPrefix(stack, "github.com/yu31/glog.TestJSONEncoder_ECSFields\n\t"), stack)
+	require.Contains(t, stack, "encoder_json_preset_test.go:")
+}
@@ -109,3 +109,41 @@ func TestJSONEncoder_AddFields(t *testing.T) {
 
 	require.Equal(t, eb.Len(), 0)
 }
+
+func TestJSONEncoderFunc(t *testing.T) {
+	var b bytes.Buffer
+	f := JSONEncoderFunc(&JSONEncoderConfig{
+		MessageKey:  "msg",
+		TimeKey:     "@timestamp",
+		LevelKey:    "severity",
+		LevelFormat: LevelFormatUpper,
+		TimeLayout:  TimeFormatUnixMilli,
+	})
+	l := NewDefault().WithEncoderFunc(f).WithExporter(StandardExporter(&b)).WithCaller(true)
+
+	l.Warn().Msg("test config").Fire()
+
+	m := make(map[string]interface{})
+	require.Nil(t, json.Unmarshal(b.Bytes(), &m), b.String())
+	require.Equal(t, "test config", m["msg"])
+	require.Equal(t, "WARN", m["severity"])
+	require.IsType(t, float64(0), m["@timestamp"])
+	require.Contains(t, m["caller"], "encoder_json_test.go")
+	require.Equal(t, 4, len(m))
+
+	b.Reset()
+	l.WithEncoderFunc(JSONEncoderFunc(&JSONEncoderConfig{LevelFormat: LevelFormatNumeric}))
+	l.Error().Msg("test numeric").Fire()
+
+	m = make(map[string]interface{})
+	require.Nil(t, json.Unmarshal(b.Bytes(), &m), b.String())
+	require.Equal(t, float64(ErrorLevel), m["level"])
+	require.Equal(t, "test numeric", m["message"])
+	require.IsType(t, "", m["time"])
+
+	// The nil config is the same as JSONEncoder.
+	b.Reset()
+	l.WithEncoderFunc(JSONEncoderFunc(nil)).WithCaller(false)
+	l.Info().Msg("test default").Fire()
+	require.Contains(t, b.String(), `"level":"info","message":"test default"}`)
+}
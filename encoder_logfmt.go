@@ -0,0 +1,471 @@
+package glog
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yu31/glog/pkg/buffer"
+)
+
+var (
+	_ Encoder      = (*logfmtEncoder)(nil)
+	_ StackEncoder = (*logfmtEncoder)(nil)
+)
+
+var _logfmtBufferPool = buffer.NewPool()
+
+// LogfmtEncoder return a new encoder implements by logfmtEncoder.
+//
+// The output is a line of space-separated key=value pairs, e.g.
+// `time=2020-11-04T18:06:13.151354+08:00 level=info msg="hello world" k=v`.
+// Values are quoted only if necessary, and the nested objects and arrays
+// are flattened to dotted keys, e.g. `user.name=yu user.tags.0=admin`.
+func LogfmtEncoder() Encoder { return newLogfmtEncoder() }
+
+func newLogfmtEncoder() *logfmtEncoder {
+	enc := &logfmtEncoder{
+		buf: _logfmtBufferPool.Get(),
+	}
+	return enc
+}
+
+type logfmtEncoder struct {
+	buf *buffer.Buffer
+
+	// prefix is the dotted key of the object or array being encoded.
+	prefix []byte
+	// index is the index of the next element in the array being encoded.
+	index int
+}
+
+// Bytes Implements encoder
+func (enc *logfmtEncoder) Bytes() []byte {
+	return enc.buf.Bytes()
+}
+func (enc *logfmtEncoder) Close() error {
+	enc.buf.Free()
+	enc.buf = nil
+	return nil
+}
+
+// AddBeginMarker Implements BuildEncoder.
+func (enc *logfmtEncoder) AddBeginMarker() {}
+func (enc *logfmtEncoder) AddEndMarker()   {}
+func (enc *logfmtEncoder) AddLineBreak()   { enc.buf.AppendByte('\n') }
+func (enc *logfmtEncoder) AddMsg(msg string) {
+	enc.appendKey("msg")
+	enc.appendString(msg)
+}
+func (enc *logfmtEncoder) AddEntryTime(t time.Time, layout string) {
+	enc.appendKey("time")
+	enc.appendTime(t, layout)
+}
+func (enc *logfmtEncoder) AddLevel(level Level) {
+	enc.appendKey("level")
+	enc.appendString(level.String())
+}
+func (enc *logfmtEncoder) AddCaller(frame runtime.Frame, layout int8) {
+	enc.appendKey("caller")
+	var arr [256]byte
+	bs := append(arr[:0], CallerFile(frame.File, layout)...)
+	bs = append(bs, ':')
+	bs = strconv.AppendInt(bs, int64(frame.Line), 10)
+	enc.appendBytes(bs)
+	if frame.Function != "" {
+		enc.appendKey("func")
+		enc.appendString(CallerFunc(frame.Function, layout))
+	}
+}
+func (enc *logfmtEncoder) AddStack(pcs []uintptr) {
+	if len(pcs) == 0 {
+		return
+	}
+	n, idx := enc.pushKey("stack")
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		nn, nIdx := enc.pushIndex()
+		enc.AddString("func", frame.Function)
+		enc.AddString("file", frame.File)
+		enc.AddInt64("line", int64(frame.Line))
+		enc.pop(nn, nIdx)
+		if !more {
+			break
+		}
+	}
+	enc.pop(n, idx)
+}
+func (enc *logfmtEncoder) WriteIn(p []byte) error {
+	if len(p) == 0 {
+		return nil
+	}
+	enc.appendElementSeparator()
+	_, err := enc.buf.Write(p)
+	return err
+}
+
+// AddByte Implements ObjectEncoder.
+func (enc *logfmtEncoder) AddByte(k string, b byte)       { enc.appendKey(k); enc.appendByteInt(b) }
+func (enc *logfmtEncoder) AddString(k string, s string)   { enc.appendKey(k); enc.appendString(s) }
+func (enc *logfmtEncoder) AddBool(k string, v bool)       { enc.appendKey(k); enc.appendBool(v) }
+func (enc *logfmtEncoder) AddInt64(k string, i int64)     { enc.appendKey(k); enc.appendInt64(i) }
+func (enc *logfmtEncoder) AddUnt64(k string, i uint64)    { enc.appendKey(k); enc.appendUint64(i) }
+func (enc *logfmtEncoder) AddFloat64(k string, f float64) { enc.appendKey(k); enc.appendFloat(f) }
+func (enc *logfmtEncoder) AddComplex128(k string, c complex128) {
+	enc.appendKey(k)
+	enc.appendComplex128(c)
+}
+func (enc *logfmtEncoder) AddRawBytes(k string, bs []byte) { enc.appendKey(k); enc.appendBytes(bs) }
+func (enc *logfmtEncoder) AddRawString(k string, s string) { enc.appendKey(k); enc.appendString(s) }
+func (enc *logfmtEncoder) AddTime(k string, t time.Time, layout string) {
+	enc.appendKey(k)
+	enc.appendTime(t, layout)
+}
+func (enc *logfmtEncoder) AddDuration(k string, d time.Duration, layout int8) {
+	enc.appendKey(k)
+	enc.appendDuration(d, layout)
+}
+func (enc *logfmtEncoder) AddArray(k string, am ArrayMarshaler) error {
+	n, idx := enc.pushKey(k)
+	return enc.appendArray(am, n, idx)
+}
+func (enc *logfmtEncoder) AddObject(k string, om ObjectMarshaler) error {
+	n, idx := enc.pushKey(k)
+	return enc.appendObject(om, n, idx)
+}
+func (enc *logfmtEncoder) AddInterface(k string, i interface{}) error {
+	enc.appendKey(k)
+	return enc.appendInterface(i)
+}
+
+// AppendByte Implements FieldEncoder.
+func (enc *logfmtEncoder) AppendByte(v byte)       { enc.appendIndexKey(); enc.appendByteInt(v) }
+func (enc *logfmtEncoder) AppendString(s string)   { enc.appendIndexKey(); enc.appendString(s) }
+func (enc *logfmtEncoder) AppendBool(v bool)       { enc.appendIndexKey(); enc.appendBool(v) }
+func (enc *logfmtEncoder) AppendInt64(i int64)     { enc.appendIndexKey(); enc.appendInt64(i) }
+func (enc *logfmtEncoder) AppendUnt64(i uint64)    { enc.appendIndexKey(); enc.appendUint64(i) }
+func (enc *logfmtEncoder) AppendFloat64(f float64) { enc.appendIndexKey(); enc.appendFloat(f) }
+func (enc *logfmtEncoder) AppendComplex128(c complex128) {
+	enc.appendIndexKey()
+	enc.appendComplex128(c)
+}
+func (enc *logfmtEncoder) AppendRawBytes(bs []byte) {
+	enc.appendIndexKey()
+	enc.appendBytes(bs)
+}
+func (enc *logfmtEncoder) AppendRawString(s string) {
+	enc.appendIndexKey()
+	enc.appendString(s)
+}
+func (enc *logfmtEncoder) AppendDuration(d time.Duration, layout int8) {
+	enc.appendIndexKey()
+	enc.appendDuration(d, layout)
+}
+func (enc *logfmtEncoder) AppendTime(t time.Time, layout string) {
+	enc.appendIndexKey()
+	enc.appendTime(t, layout)
+}
+func (enc *logfmtEncoder) AppendArray(am ArrayMarshaler) error {
+	n, idx := enc.pushIndex()
+	return enc.appendArray(am, n, idx)
+}
+func (enc *logfmtEncoder) AppendObject(om ObjectMarshaler) error {
+	n, idx := enc.pushIndex()
+	return enc.appendObject(om, n, idx)
+}
+func (enc *logfmtEncoder) AppendInterface(i interface{}) error {
+	enc.appendIndexKey()
+	return enc.appendInterface(i)
+}
+
+// pushKey appends the key to prefix for encoding a nested object or array,
+// and returns the previous state that should be passed to pop.
+func (enc *logfmtEncoder) pushKey(key string) (n int, idx int) {
+	n, idx = len(enc.prefix), enc.index
+	if n > 0 {
+		enc.prefix = append(enc.prefix, '.')
+	}
+	enc.prefix = appendLogfmtKey(enc.prefix, key)
+	enc.index = 0
+	return
+}
+
+// pushIndex appends the index of the next element to prefix for encoding
+// a nested object or array in array, and returns the previous state that should be passed to pop.
+func (enc *logfmtEncoder) pushIndex() (n int, idx int) {
+	n, idx = len(enc.prefix), enc.index+1
+	if n > 0 {
+		enc.prefix = append(enc.prefix, '.')
+	}
+	enc.prefix = strconv.AppendInt(enc.prefix, int64(enc.index), 10)
+	enc.index = 0
+	return
+}
+
+// pop restores the state returned by pushKey or pushIndex.
+func (enc *logfmtEncoder) pop(n int, idx int) {
+	enc.prefix = enc.prefix[:n]
+	enc.index = idx
+}
+
+// Add the dotted key of current prefix and key.
+func (enc *logfmtEncoder) appendKey(key string) {
+	enc.appendElementSeparator()
+	if len(enc.prefix) > 0 {
+		_, _ = enc.buf.Write(enc.prefix)
+		enc.buf.AppendByte('.')
+	}
+	enc.appendKeyName(key)
+	enc.appendFieldSeparator()
+}
+
+// Add the key and replaces the characters that are not allowed with '_'.
+func (enc *logfmtEncoder) appendKeyName(key string) {
+	if len(key) == 0 {
+		enc.buf.AppendByte('_')
+		return
+	}
+	for i := 0; i < len(key); i++ {
+		if logfmtInvalidKeyByte(key[i]) {
+			enc.buf.AppendString(key[:i])
+			for ; i < len(key); i++ {
+				b := key[i]
+				if logfmtInvalidKeyByte(b) {
+					b = '_'
+				}
+				enc.buf.AppendByte(b)
+			}
+			return
+		}
+	}
+	enc.buf.AppendString(key)
+}
+
+// Add the dotted key of current prefix and the index of next element in array.
+func (enc *logfmtEncoder) appendIndexKey() {
+	enc.appendElementSeparator()
+	if len(enc.prefix) > 0 {
+		_, _ = enc.buf.Write(enc.prefix)
+		enc.buf.AppendByte('.')
+	}
+	enc.buf.AppendInt(int64(enc.index))
+	enc.index++
+	enc.appendFieldSeparator()
+}
+
+// Add the dotted key of current prefix with an empty value,
+// it's used for the empty object or array.
+func (enc *logfmtEncoder) appendEmpty(empty string) {
+	enc.appendElementSeparator()
+	_, _ = enc.buf.Write(enc.prefix)
+	enc.appendFieldSeparator()
+	enc.buf.AppendString(empty)
+}
+
+func (enc *logfmtEncoder) appendFieldSeparator() {
+	enc.buf.AppendByte('=')
+}
+
+// Add elements separator.
+func (enc *logfmtEncoder) appendElementSeparator() {
+	last := enc.buf.Len() - 1
+	if last < 0 {
+		return
+	}
+	switch enc.buf.Bytes()[last] {
+	case ' ', '=':
+		return
+	default:
+		enc.buf.AppendByte(' ')
+	}
+}
+
+func (enc *logfmtEncoder) appendString(s string) {
+	if !logfmtNeedsQuote(s) {
+		enc.buf.AppendString(s)
+		return
+	}
+	enc.buf.AppendByte('"')
+	AppendStringEscape(enc.buf, s)
+	enc.buf.AppendByte('"')
+}
+
+func (enc *logfmtEncoder) appendBytes(bs []byte) {
+	if !logfmtNeedsQuoteBytes(bs) {
+		_, _ = enc.buf.Write(bs)
+		return
+	}
+	enc.buf.AppendByte('"')
+	for _, b := range bs {
+		switch {
+		case b == '"' || b == '\\':
+			enc.buf.AppendByte('\\')
+			enc.buf.AppendByte(b)
+		case b == '\n':
+			enc.buf.AppendString(`\n`)
+		case b == '\r':
+			enc.buf.AppendString(`\r`)
+		case b == '\t':
+			enc.buf.AppendString(`\t`)
+		case b < 0x20:
+			enc.buf.AppendString(`\u00`)
+			enc.buf.AppendByte(hex[b>>4])
+			enc.buf.AppendByte(hex[b&0xF])
+		default:
+			enc.buf.AppendByte(b)
+		}
+	}
+	enc.buf.AppendByte('"')
+}
+
+func (enc *logfmtEncoder) appendByteInt(b byte) {
+	enc.buf.AppendUint(uint64(b))
+}
+
+func (enc *logfmtEncoder) appendInt64(i int64) {
+	enc.buf.AppendInt(i)
+}
+
+func (enc *logfmtEncoder) appendUint64(i uint64) {
+	enc.buf.AppendUint(i)
+}
+
+func (enc *logfmtEncoder) appendBool(v bool) {
+	enc.buf.AppendBool(v)
+}
+
+func (enc *logfmtEncoder) appendTime(t time.Time, layout string) {
+	switch layout {
+	case TimeFormatUnixSecond:
+		enc.buf.AppendInt(t.Unix())
+	case TimeFormatUnixMilli:
+		enc.buf.AppendInt(t.UnixNano() / 1e6)
+	case TimeFormatUnixMicro:
+		enc.buf.AppendInt(t.UnixNano() / 1e3)
+	case TimeFormatUnixNano:
+		enc.buf.AppendInt(t.UnixNano())
+	default:
+		// The layout may contain spaces, so format it before quoting.
+		var arr [64]byte
+		enc.appendBytes(t.AppendFormat(arr[:0], layout))
+	}
+}
+
+func (enc *logfmtEncoder) appendDuration(d time.Duration, layout int8) {
+	AppendDuration(enc.buf, d, layout)
+}
+
+func (enc *logfmtEncoder) appendFloat(f float64) {
+	switch {
+	// The special values are quoted as the JSONEncoder does, so that
+	// they are not parsed as numbers.
+	case math.IsNaN(f):
+		enc.buf.AppendString(`"NaN"`)
+	case math.IsInf(f, 1):
+		enc.buf.AppendString(`"+Inf"`)
+	case math.IsInf(f, -1):
+		enc.buf.AppendString(`"-Inf"`)
+	default:
+		enc.buf.AppendFloat(f, 64)
+	}
+}
+
+func (enc *logfmtEncoder) appendComplex128(c complex128) {
+	// Cast to a platform-independent, fixed-size type.
+	r, i := float64(real(c)), float64(imag(c))
+	appendComplex(enc.buf, r, i)
+}
+
+func (enc *logfmtEncoder) appendArray(am ArrayMarshaler, n int, idx int) error {
+	start := enc.buf.Len()
+	err := am.MarshalGLogArray(enc)
+	if enc.buf.Len() == start {
+		enc.appendEmpty("[]")
+	}
+	enc.pop(n, idx)
+	return err
+}
+
+func (enc *logfmtEncoder) appendObject(om ObjectMarshaler, n int, idx int) error {
+	start := enc.buf.Len()
+	err := om.MarshalGLogObject(enc)
+	if enc.buf.Len() == start {
+		enc.appendEmpty("{}")
+	}
+	enc.pop(n, idx)
+	return err
+}
+
+func (enc *logfmtEncoder) appendInterface(i interface{}) error {
+	switch i.(type) {
+	case nil:
+		enc.buf.AppendString("<nil>")
+		return nil
+	default:
+		enc.appendString(fmt.Sprintf("%+v", i))
+	}
+	return nil
+}
+
+// logfmtNeedsQuote reports whether the value must be quoted in logfmt.
+func logfmtNeedsQuote(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b <= ' ' || b == '=' || b == '"' || b == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// logfmtNeedsQuoteBytes is like logfmtNeedsQuote but for ASCII bytes,
+// the bytes above the ascii boundary are written as is.
+func logfmtNeedsQuoteBytes(bs []byte) bool {
+	if len(bs) == 0 {
+		return true
+	}
+	for _, b := range bs {
+		if b <= ' ' || b == '=' || b == '"' || b == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// appendLogfmtKey appends the key to dst and replaces the characters
+// that are not allowed in logfmt key with '_'.
+func appendLogfmtKey(dst []byte, key string) []byte {
+	if len(key) == 0 {
+		return append(dst, '_')
+	}
+	for i := 0; i < len(key); i++ {
+		b := key[i]
+		if logfmtInvalidKeyByte(b) {
+			b = '_'
+		}
+		dst = append(dst, b)
+	}
+	return dst
+}
+
+// logfmtInvalidKeyByte reports whether the byte is not allowed in logfmt key.
+func logfmtInvalidKeyByte(b byte) bool {
+	return b <= ' ' || b == '=' || b == '"' || b == 0x7f
+}
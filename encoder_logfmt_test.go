@@ -0,0 +1,135 @@
+package glog
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogfmtEncoder_AddArray(t *testing.T) {
+	enc := LogfmtEncoder()
+	defer func() {
+		_ = enc.Close()
+	}()
+
+	n := 3
+	ts := make(timeArray, 0, n)
+	for i := 0; i < n; i++ {
+		ts = append(ts, time.Now())
+	}
+
+	enc.AddBeginMarker()
+	require.Nil(t, enc.AddArray("time1", ts))
+	require.Nil(t, enc.AddArray("time2", ts))
+	require.Nil(t, enc.AddArray("empty", timeArray{}))
+	enc.AddEndMarker()
+
+	s := string(enc.Bytes())
+
+	require.True(t, strings.HasPrefix(s, "time1.0="))
+	require.Contains(t, s, " time1.2=")
+	require.Contains(t, s, " time2.0=")
+	require.Contains(t, s, " empty=[]")
+	require.NotContains(t, s, "time1.3=")
+}
+
+func TestLogfmtEncoder_AddObject(t *testing.T) {
+	enc := LogfmtEncoder()
+	defer func() {
+		_ = enc.Close()
+	}()
+
+	var infos infos
+	infos = append(infos, &info{
+		Name:  "aa",
+		Sex:   "man",
+		Age:   999,
+		Times: timeArray{time.Now(), time.Now()},
+	})
+	infos = append(infos, &info{
+		Name:  "bb",
+		Sex:   "man",
+		Age:   999,
+		Times: timeArray{time.Now(), time.Now()},
+	})
+	infos = append(infos, &info{
+		Name:  "cc c",
+		Sex:   "man",
+		Age:   999,
+		Times: timeArray{},
+	})
+
+	enc.AddBeginMarker()
+	require.Nil(t, enc.AddArray("infos", infos))
+	require.Nil(t, enc.AddObject("info", infos[0]))
+	enc.AddEndMarker()
+
+	s := string(enc.Bytes())
+	require.Equal(t, 4, strings.Count(s, "name="))
+	require.Contains(t, s, "infos.0.name=aa infos.0.sex=man infos.0.age=999 infos.0.times.0=")
+	require.Contains(t, s, " infos.0.times.1=")
+	require.Contains(t, s, ` infos.2.name="cc c" infos.2.sex=man infos.2.age=999 infos.2.times=[]`)
+	require.Contains(t, s, " info.name=aa info.sex=man info.age=999 info.times.0=")
+}
+
+func TestLogfmtEncoder_Quote(t *testing.T) {
+	enc := LogfmtEncoder()
+	defer func() {
+		_ = enc.Close()
+	}()
+
+	enc.AddString("plain", "value")
+	enc.AddString("empty", "")
+	enc.AddString("space", "hello world")
+	enc.AddString("equal", "a=b")
+	enc.AddString("quote", `say "hi"`)
+	enc.AddString("newline", "line1\nline2")
+	enc.AddString("unicode", "中文")
+	enc.AddString("bad key", "v")
+	enc.AddRawBytes("raw", []byte(`{"a":1}`))
+	enc.AddTime("time", time.Date(2020, 11, 4, 18, 6, 13, 0, time.UTC), "2006-01-02 15:04:05")
+	enc.AddFloat64("zero", 0)
+	enc.AddFloat64("nan", math.NaN())
+	enc.AddFloat64("inf", math.Inf(1))
+	enc.AddFloat64("neg_inf", math.Inf(-1))
+	enc.AddComplex128("complex", 1+2i)
+	enc.AddComplex128("neg_complex", 1-2i)
+	require.Nil(t, enc.AddInterface("nil", nil))
+
+	s := string(enc.Bytes())
+	require.Equal(t, `plain=value empty="" space="hello world" equal="a=b" quote="say \"hi\""`+
+		` newline="line1\nline2" unicode=中文 bad_key=v raw="{\"a\":1}" time="2020-11-04 18:06:13"`+
+		` zero=0 nan="NaN" inf="+Inf" neg_inf="-Inf" complex=1+2i neg_complex=1-2i nil=<nil>`, s)
+}
+
+func TestLogfmtEncoder_AddFields(t *testing.T) {
+	var eb bytes.Buffer
+	var b bytes.Buffer
+	l := NewDefault().WithEncoderFunc(LogfmtEncoder).WithExporter(StandardExporter(&b)).
+		WithCaller(true).WithCallerFormat(CallerFormatShort).WithErrorOutput(&eb)
+
+	l.WithFields().AddString("rid", "xxxxxx01")
+	l.WithFields().AddString("tid", "yyyyyy02")
+
+	l.Info().
+		Msg("test logger out").
+		String("String", "Value").
+		Fire()
+
+	s := b.String()
+	require.True(t, strings.HasPrefix(s, "time="))
+	require.True(t, strings.HasSuffix(s, "\n"))
+	require.Contains(t, s, ` level=info msg="test logger out" String=Value rid=xxxxxx01 tid=yyyyyy02 caller=`)
+	require.Contains(t, s, "/encoder_logfmt_test.go:")
+	require.Equal(t, eb.Len(), 0)
+
+	b.Reset()
+	l.Info().Msg("stack").Stack().Fire()
+	require.Contains(t, b.String(), " stack.0.func=")
+	require.Contains(t, b.String(), " stack.0.file=")
+	require.Contains(t, b.String(), " stack.0.line=")
+}
@@ -0,0 +1,196 @@
+package glog
+
+import (
+	"runtime"
+	"time"
+)
+
+var _ Encoder = (*multiEncoder)(nil)
+
+// multiEncoder wraps the Encoder of Logger and the Encoders of EncoderExporters,
+// it forwards every call to all of them.
+//
+// Except WriteIn and the raw fields only write to the Encoder of Logger, since
+// the data is encoded by it; Use addFields to add the fixed fields into the others.
+type multiEncoder struct {
+	Encoder
+	others []Encoder
+}
+
+func newMultiEncoder(enc Encoder, encoders []*encoderExporter) *multiEncoder {
+	me := &multiEncoder{
+		Encoder: enc,
+		others:  make([]Encoder, len(encoders)),
+	}
+	for i := range encoders {
+		me.others[i] = encoders[i].f()
+	}
+	return me
+}
+
+// addFields adds the captured fixed fields except the raw fields into the
+// Encoders except the Encoder of Logger.
+func (me *multiEncoder) addFields(fields []Field) error {
+	var err error
+	for _, enc := range me.others {
+		for i := range fields {
+			if fields[i].Type == FieldTypeRawBytes || fields[i].Type == FieldTypeRawString {
+				continue
+			}
+			if e := fields[i].encode(enc); e != nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// Close Implements Encoder.
+func (me *multiEncoder) Close() error {
+	err := me.Encoder.Close()
+	for _, enc := range me.others {
+		if e := enc.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// AddBeginMarker Implements BuildEncoder.
+func (me *multiEncoder) AddBeginMarker() {
+	me.Encoder.AddBeginMarker()
+	for _, enc := range me.others {
+		enc.AddBeginMarker()
+	}
+}
+func (me *multiEncoder) AddEndMarker() {
+	me.Encoder.AddEndMarker()
+	for _, enc := range me.others {
+		enc.AddEndMarker()
+	}
+}
+func (me *multiEncoder) AddLineBreak() {
+	me.Encoder.AddLineBreak()
+	for _, enc := range me.others {
+		enc.AddLineBreak()
+	}
+}
+func (me *multiEncoder) AddMsg(msg string) {
+	me.Encoder.AddMsg(msg)
+	for _, enc := range me.others {
+		enc.AddMsg(msg)
+	}
+}
+func (me *multiEncoder) AddEntryTime(t time.Time, layout string) {
+	me.Encoder.AddEntryTime(t, layout)
+	for _, enc := range me.others {
+		enc.AddEntryTime(t, layout)
+	}
+}
+func (me *multiEncoder) AddLevel(level Level) {
+	me.Encoder.AddLevel(level)
+	for _, enc := range me.others {
+		enc.AddLevel(level)
+	}
+}
+func (me *multiEncoder) AddCaller(frame runtime.Frame, layout int8) {
+	me.Encoder.AddCaller(frame, layout)
+	for _, enc := range me.others {
+		enc.AddCaller(frame, layout)
+	}
+}
+func (me *multiEncoder) AddStack(pcs []uintptr) {
+	addStack(me.Encoder, pcs)
+	for _, enc := range me.others {
+		addStack(enc, pcs)
+	}
+}
+
+// AddByte Implements ObjectEncoder.
+func (me *multiEncoder) AddByte(k string, b byte) {
+	me.Encoder.AddByte(k, b)
+	for _, enc := range me.others {
+		enc.AddByte(k, b)
+	}
+}
+func (me *multiEncoder) AddString(k string, s string) {
+	me.Encoder.AddString(k, s)
+	for _, enc := range me.others {
+		enc.AddString(k, s)
+	}
+}
+func (me *multiEncoder) AddBool(k string, v bool) {
+	me.Encoder.AddBool(k, v)
+	for _, enc := range me.others {
+		enc.AddBool(k, v)
+	}
+}
+func (me *multiEncoder) AddInt64(k string, i int64) {
+	me.Encoder.AddInt64(k, i)
+	for _, enc := range me.others {
+		enc.AddInt64(k, i)
+	}
+}
+func (me *multiEncoder) AddUnt64(k string, i uint64) {
+	me.Encoder.AddUnt64(k, i)
+	for _, enc := range me.others {
+		enc.AddUnt64(k, i)
+	}
+}
+func (me *multiEncoder) AddFloat64(k string, f float64) {
+	me.Encoder.AddFloat64(k, f)
+	for _, enc := range me.others {
+		enc.AddFloat64(k, f)
+	}
+}
+func (me *multiEncoder) AddComplex128(k string, c complex128) {
+	me.Encoder.AddComplex128(k, c)
+	for _, enc := range me.others {
+		enc.AddComplex128(k, c)
+	}
+}
+func (me *multiEncoder) AddRawBytes(k string, bs []byte) {
+	me.Encoder.AddRawBytes(k, bs)
+}
+func (me *multiEncoder) AddRawString(k string, s string) {
+	me.Encoder.AddRawString(k, s)
+}
+func (me *multiEncoder) AddTime(k string, t time.Time, layout string) {
+	me.Encoder.AddTime(k, t, layout)
+	for _, enc := range me.others {
+		enc.AddTime(k, t, layout)
+	}
+}
+func (me *multiEncoder) AddDuration(k string, d time.Duration, layout int8) {
+	me.Encoder.AddDuration(k, d, layout)
+	for _, enc := range me.others {
+		enc.AddDuration(k, d, layout)
+	}
+}
+func (me *multiEncoder) AddArray(k string, am ArrayMarshaler) error {
+	err := me.Encoder.AddArray(k, am)
+	for _, enc := range me.others {
+		if e := enc.AddArray(k, am); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+func (me *multiEncoder) AddObject(k string, om ObjectMarshaler) error {
+	err := me.Encoder.AddObject(k, om)
+	for _, enc := range me.others {
+		if e := enc.AddObject(k, om); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+func (me *multiEncoder) AddInterface(k string, i interface{}) error {
+	err := me.Encoder.AddInterface(k, i)
+	for _, enc := range me.others {
+		if e := enc.AddInterface(k, i); e != nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,475 @@
+package glog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/yu31/glog/pkg/buffer"
+)
+
+var (
+	_ Encoder      = (*protoEncoder)(nil)
+	_ StackEncoder = (*protoEncoder)(nil)
+)
+
+var _protoBufferPool = buffer.NewPool()
+
+// The wire types of protocol buffers.
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+)
+
+const (
+	// protoLenSize is the width of the slot reserved for the length of every
+	// message, the length is backfilled as a padded varint after the message
+	// be encoded, so that the content needn't be moved.
+	protoLenSize = 4
+	// protoMaxLen is the maximum length that fits in the slot.
+	protoMaxLen = 1<<(7*protoLenSize) - 1
+	// protoLenSlot is the placeholder of the slot.
+	protoLenSlot = "\x00\x00\x00\x00"
+)
+
+// The field numbers of the messages encoded by protoEncoder.
+//
+// The schema in proto3 syntax:
+//
+//	message Record {
+//	  repeated Field fields = 1;
+//	  sfixed64 time = 2;       // Unix time in nanoseconds.
+//	  sint32 level = 3;
+//	  string message = 4;
+//	  Frame caller = 5;
+//	  repeated Frame stack = 6;
+//	}
+//
+//	message Field {
+//	  string key = 1;          // Empty for the element of array.
+//	  oneof value {            // No value for nil.
+//	    sint64 int = 2;
+//	    uint64 uint = 3;
+//	    double float = 4;
+//	    string string = 5;
+//	    bool bool = 6;
+//	    bytes raw = 7;         // The already serialized data, or the JSON of interface.
+//	    sfixed64 time = 8;     // Unix time in nanoseconds.
+//	    sint64 duration = 9;   // Nanoseconds.
+//	    Fields array = 10;
+//	    Fields object = 11;
+//	    Complex complex = 12;
+//	  }
+//	}
+//
+//	message Fields { repeated Field fields = 1; }
+//	message Complex { double real = 1; double imag = 2; }
+//	message Frame { string file = 1; int64 line = 2; string function = 3; }
+const (
+	protoRecordFields  = 1
+	protoRecordTime    = 2
+	protoRecordLevel   = 3
+	protoRecordMessage = 4
+	protoRecordCaller  = 5
+	protoRecordStack   = 6
+
+	protoFieldKey      = 1
+	protoFieldInt      = 2
+	protoFieldUint     = 3
+	protoFieldFloat    = 4
+	protoFieldString   = 5
+	protoFieldBool     = 6
+	protoFieldRaw      = 7
+	protoFieldTime     = 8
+	protoFieldDuration = 9
+	protoFieldArray    = 10
+	protoFieldObject   = 11
+	protoFieldComplex  = 12
+
+	protoFieldsFields = 1
+
+	protoComplexReal = 1
+	protoComplexImag = 2
+
+	protoFrameFile     = 1
+	protoFrameLine     = 2
+	protoFrameFunction = 3
+)
+
+// ProtoEncoder return a new encoder implements by protoEncoder.
+//
+// Every entry is encoded as a Record message in the protocol buffers wire format,
+// and prefixed with its length in varint, so the records can be sent over
+// pipes and sockets without line break; Use ProtoReader to read them.
+// The lengths of record and nested messages are padded to 4 bytes at least.
+// The time layouts and duration layouts are ignored, they are always encoded
+// in nanoseconds.
+func ProtoEncoder() Encoder { return newProtoEncoder() }
+
+func newProtoEncoder() *protoEncoder {
+	enc := &protoEncoder{
+		buf:   _protoBufferPool.Get(),
+		start: -1,
+	}
+	return enc
+}
+
+type protoEncoder struct {
+	buf *buffer.Buffer
+
+	// start is the offset of the record that set by AddBeginMarker, -1 if not set.
+	start int
+}
+
+// Bytes Implements encoder.
+func (enc *protoEncoder) Bytes() []byte {
+	return enc.buf.Bytes()
+}
+func (enc *protoEncoder) Close() error {
+	enc.buf.Free()
+	enc.buf = nil
+	return nil
+}
+
+// AddBeginMarker Implements BuildEncoder.
+func (enc *protoEncoder) AddBeginMarker() {
+	enc.start = enc.buf.Len()
+	enc.buf.AppendString(protoLenSlot)
+}
+func (enc *protoEncoder) AddEndMarker() {
+	if enc.start >= 0 {
+		enc.endMessage(enc.start)
+		enc.start = -1
+	}
+}
+func (enc *protoEncoder) AddLineBreak() {}
+func (enc *protoEncoder) AddMsg(msg string) {
+	enc.appendString(protoRecordMessage, msg)
+}
+func (enc *protoEncoder) AddEntryTime(t time.Time, layout string) {
+	enc.appendFixed64(protoRecordTime, uint64(t.UnixNano()))
+}
+func (enc *protoEncoder) AddLevel(level Level) {
+	enc.appendSint64(protoRecordLevel, int64(level))
+}
+func (enc *protoEncoder) AddCaller(frame runtime.Frame, layout int8) {
+	enc.appendFrame(protoRecordCaller, CallerFile(frame.File, layout), frame.Line, CallerFunc(frame.Function, layout))
+}
+func (enc *protoEncoder) AddStack(pcs []uintptr) {
+	if len(pcs) == 0 {
+		return
+	}
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		enc.appendFrame(protoRecordStack, frame.File, frame.Line, frame.Function)
+		if !more {
+			break
+		}
+	}
+}
+func (enc *protoEncoder) WriteIn(p []byte) error {
+	_, err := enc.buf.Write(p)
+	return err
+}
+
+// AddByte Implements ObjectEncoder.
+func (enc *protoEncoder) AddByte(k string, b byte) {
+	start := enc.beginField(k)
+	enc.appendUvarint(protoFieldUint, uint64(b))
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddString(k string, s string) {
+	start := enc.beginField(k)
+	enc.appendString(protoFieldString, s)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddBool(k string, v bool) {
+	start := enc.beginField(k)
+	enc.appendBool(protoFieldBool, v)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddInt64(k string, i int64) {
+	start := enc.beginField(k)
+	enc.appendSint64(protoFieldInt, i)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddUnt64(k string, i uint64) {
+	start := enc.beginField(k)
+	enc.appendUvarint(protoFieldUint, i)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddFloat64(k string, f float64) {
+	start := enc.beginField(k)
+	enc.appendFixed64(protoFieldFloat, math.Float64bits(f))
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddComplex128(k string, c complex128) {
+	start := enc.beginField(k)
+	enc.appendComplex128(c)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddRawBytes(k string, bs []byte) {
+	start := enc.beginField(k)
+	enc.appendBytes(protoFieldRaw, bs)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddRawString(k string, s string) {
+	start := enc.beginField(k)
+	enc.appendString(protoFieldRaw, s)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddTime(k string, t time.Time, layout string) {
+	start := enc.beginField(k)
+	enc.appendFixed64(protoFieldTime, uint64(t.UnixNano()))
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddDuration(k string, d time.Duration, layout int8) {
+	start := enc.beginField(k)
+	enc.appendSint64(protoFieldDuration, int64(d))
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AddArray(k string, am ArrayMarshaler) error {
+	start := enc.beginField(k)
+	err := enc.appendArray(am)
+	enc.endMessage(start)
+	return err
+}
+func (enc *protoEncoder) AddObject(k string, om ObjectMarshaler) error {
+	start := enc.beginField(k)
+	err := enc.appendObject(om)
+	enc.endMessage(start)
+	return err
+}
+func (enc *protoEncoder) AddInterface(k string, i interface{}) error {
+	start := enc.beginField(k)
+	err := enc.appendInterface(i)
+	enc.endMessage(start)
+	return err
+}
+
+// AppendByte Implements FieldEncoder.
+func (enc *protoEncoder) AppendByte(b byte) {
+	start := enc.beginElement()
+	enc.appendUvarint(protoFieldUint, uint64(b))
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendString(s string) {
+	start := enc.beginElement()
+	enc.appendString(protoFieldString, s)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendBool(v bool) {
+	start := enc.beginElement()
+	enc.appendBool(protoFieldBool, v)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendInt64(i int64) {
+	start := enc.beginElement()
+	enc.appendSint64(protoFieldInt, i)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendUnt64(i uint64) {
+	start := enc.beginElement()
+	enc.appendUvarint(protoFieldUint, i)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendFloat64(f float64) {
+	start := enc.beginElement()
+	enc.appendFixed64(protoFieldFloat, math.Float64bits(f))
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendComplex128(c complex128) {
+	start := enc.beginElement()
+	enc.appendComplex128(c)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendRawBytes(bs []byte) {
+	start := enc.beginElement()
+	enc.appendBytes(protoFieldRaw, bs)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendRawString(s string) {
+	start := enc.beginElement()
+	enc.appendString(protoFieldRaw, s)
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendDuration(d time.Duration, layout int8) {
+	start := enc.beginElement()
+	enc.appendSint64(protoFieldDuration, int64(d))
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendTime(t time.Time, layout string) {
+	start := enc.beginElement()
+	enc.appendFixed64(protoFieldTime, uint64(t.UnixNano()))
+	enc.endMessage(start)
+}
+func (enc *protoEncoder) AppendArray(am ArrayMarshaler) error {
+	start := enc.beginElement()
+	err := enc.appendArray(am)
+	enc.endMessage(start)
+	return err
+}
+func (enc *protoEncoder) AppendObject(om ObjectMarshaler) error {
+	start := enc.beginElement()
+	err := enc.appendObject(om)
+	enc.endMessage(start)
+	return err
+}
+func (enc *protoEncoder) AppendInterface(i interface{}) error {
+	start := enc.beginElement()
+	err := enc.appendInterface(i)
+	enc.endMessage(start)
+	return err
+}
+
+// beginField begins a Field message with key, it returns the offset that
+// should be passed to endMessage.
+func (enc *protoEncoder) beginField(k string) int {
+	start := enc.beginMessage(protoRecordFields)
+	enc.appendString(protoFieldKey, k)
+	return start
+}
+
+// beginElement begins a Field message without key for the element of array.
+func (enc *protoEncoder) beginElement() int {
+	return enc.beginMessage(protoFieldsFields)
+}
+
+// beginMessage adds the tag of an embedded message, it returns the offset
+// of the message that should be passed to endMessage.
+func (enc *protoEncoder) beginMessage(num int) int {
+	enc.appendTag(num, protoWireBytes)
+	start := enc.buf.Len()
+	enc.buf.AppendString(protoLenSlot)
+	return start
+}
+
+// endMessage backfills the length of the message into the slot at the offset.
+func (enc *protoEncoder) endMessage(start int) {
+	n := enc.buf.Len() - start - protoLenSize
+	slot := enc.buf.Bytes()[start : start+protoLenSize]
+	if n <= protoMaxLen {
+		v := uint64(n)
+		for i := 0; i < protoLenSize-1; i++ {
+			slot[i] = byte(v) | 0x80
+			v >>= 7
+		}
+		slot[protoLenSize-1] = byte(v)
+		return
+	}
+	// The length is too large for the slot, insert the remaining bytes after it.
+	var arr [binary.MaxVarintLen64]byte
+	size := binary.PutUvarint(arr[:], uint64(n))
+	copy(slot, arr[:protoLenSize])
+	_, _ = enc.buf.Write(arr[protoLenSize:size])
+	rotateBytes(enc.buf.Bytes()[start+protoLenSize:], n)
+}
+
+func (enc *protoEncoder) appendVarint(v uint64) {
+	for v >= 0x80 {
+		enc.buf.AppendByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	enc.buf.AppendByte(byte(v))
+}
+
+func (enc *protoEncoder) appendTag(num int, wireType int) {
+	enc.appendVarint(uint64(num)<<3 | uint64(wireType))
+}
+
+func (enc *protoEncoder) appendUvarint(num int, v uint64) {
+	enc.appendTag(num, protoWireVarint)
+	enc.appendVarint(v)
+}
+
+func (enc *protoEncoder) appendSint64(num int, v int64) {
+	enc.appendTag(num, protoWireVarint)
+	enc.appendVarint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+func (enc *protoEncoder) appendBool(num int, v bool) {
+	enc.appendTag(num, protoWireVarint)
+	if v {
+		enc.buf.AppendByte(1)
+	} else {
+		enc.buf.AppendByte(0)
+	}
+}
+
+func (enc *protoEncoder) appendFixed64(num int, v uint64) {
+	enc.appendTag(num, protoWireFixed64)
+	for i := 0; i < 8; i++ {
+		enc.buf.AppendByte(byte(v >> (8 * i)))
+	}
+}
+
+func (enc *protoEncoder) appendString(num int, s string) {
+	enc.appendTag(num, protoWireBytes)
+	enc.appendVarint(uint64(len(s)))
+	enc.buf.AppendString(s)
+}
+
+func (enc *protoEncoder) appendBytes(num int, bs []byte) {
+	enc.appendTag(num, protoWireBytes)
+	enc.appendVarint(uint64(len(bs)))
+	_, _ = enc.buf.Write(bs)
+}
+
+func (enc *protoEncoder) appendFrame(num int, file string, line int, function string) {
+	start := enc.beginMessage(num)
+	enc.appendString(protoFrameFile, file)
+	enc.appendUvarint(protoFrameLine, uint64(line))
+	if function != "" {
+		enc.appendString(protoFrameFunction, function)
+	}
+	enc.endMessage(start)
+}
+
+func (enc *protoEncoder) appendComplex128(c complex128) {
+	start := enc.beginMessage(protoFieldComplex)
+	enc.appendFixed64(protoComplexReal, math.Float64bits(real(c)))
+	enc.appendFixed64(protoComplexImag, math.Float64bits(imag(c)))
+	enc.endMessage(start)
+}
+
+func (enc *protoEncoder) appendArray(am ArrayMarshaler) error {
+	start := enc.beginMessage(protoFieldArray)
+	err := am.MarshalGLogArray(enc)
+	enc.endMessage(start)
+	return err
+}
+
+func (enc *protoEncoder) appendObject(om ObjectMarshaler) error {
+	start := enc.beginMessage(protoFieldObject)
+	err := om.MarshalGLogObject(enc)
+	enc.endMessage(start)
+	return err
+}
+
+func (enc *protoEncoder) appendInterface(i interface{}) error {
+	var err error
+	var b []byte
+
+	switch m := i.(type) {
+	case json.Marshaler:
+		b, err = m.MarshalJSON()
+	case nil:
+		// The Field without value represents nil.
+		return nil
+	default:
+		b, err = json.Marshal(i)
+	}
+
+	if err != nil {
+		enc.appendString(protoFieldString, fmt.Sprintf("%+v", i))
+		return err
+	}
+	enc.appendBytes(protoFieldRaw, b)
+	return nil
+}
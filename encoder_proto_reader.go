@@ -0,0 +1,403 @@
+package glog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	// protoMaxRecordSize is the maximum size of a record in ProtoReader.
+	protoMaxRecordSize = 64 << 20
+	// protoMaxDepth is the maximum nesting depth of arrays and objects in ProtoReader.
+	protoMaxDepth = 256
+)
+
+var (
+	errProtoTruncated = errors.New("glog: proto message is truncated")
+	errProtoMaxDepth  = errors.New("glog: proto nesting depth exceeds the limit")
+	errProtoTooLarge  = errors.New("glog: proto record size exceeds the limit")
+)
+
+// ProtoRecord is a record decoded by ProtoReader.
+type ProtoRecord struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Caller  *ProtoFrame
+	Stack   []ProtoFrame
+	Fields  []ProtoField
+}
+
+// ProtoFrame is the caller or a frame of stack in ProtoRecord.
+type ProtoFrame struct {
+	File     string
+	Line     int
+	Function string
+}
+
+// ProtoField is a field decoded by ProtoReader.
+//
+// The Value is one of: nil, int64, uint64, float64, string, bool, []byte (raw),
+// time.Time, time.Duration, complex128, []interface{} (array)
+// and []ProtoField (object).
+type ProtoField struct {
+	Key   string
+	Value interface{}
+}
+
+// ProtoReader reads the records encoded by ProtoEncoder from an io.Reader.
+type ProtoReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// NewProtoReader return a ProtoReader that reads from r.
+func NewProtoReader(r io.Reader) *ProtoReader {
+	return &ProtoReader{r: bufio.NewReader(r)}
+}
+
+// ReadFrame returns the bytes of next record without length prefix.
+// The bytes is only valid until the next call.
+// It returns io.EOF if no more records, and io.ErrUnexpectedEOF if
+// the last record is truncated.
+func (pr *ProtoReader) ReadFrame() ([]byte, error) {
+	n, err := binary.ReadUvarint(pr.r)
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("glog: proto invalid record length: %w", err)
+	}
+	if n > protoMaxRecordSize {
+		return nil, errProtoTooLarge
+	}
+	if uint64(cap(pr.buf)) < n {
+		pr.buf = make([]byte, n)
+	}
+	pr.buf = pr.buf[:n]
+	if _, err = io.ReadFull(pr.r, pr.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return pr.buf, nil
+}
+
+// Next reads and decodes the next record.
+// It returns io.EOF if no more records.
+func (pr *ProtoReader) Next() (*ProtoRecord, error) {
+	frame, err := pr.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	return DecodeProtoRecord(frame)
+}
+
+// DecodeProtoRecord decodes a record without length prefix, e.g. returned by ReadFrame.
+func DecodeProtoRecord(p []byte) (*ProtoRecord, error) {
+	record := &ProtoRecord{}
+	d := protoDecoder{p: p}
+	for !d.done() {
+		num, wireType, err := d.readTag()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case num == protoRecordFields && wireType == protoWireBytes:
+			b, err := d.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			field, err := decodeProtoField(b, 0)
+			if err != nil {
+				return nil, err
+			}
+			record.Fields = append(record.Fields, field)
+		case num == protoRecordTime && wireType == protoWireFixed64:
+			v, err := d.readFixed64()
+			if err != nil {
+				return nil, err
+			}
+			record.Time = time.Unix(0, int64(v))
+		case num == protoRecordLevel && wireType == protoWireVarint:
+			v, err := d.readSint64()
+			if err != nil {
+				return nil, err
+			}
+			record.Level = Level(v)
+		case num == protoRecordMessage && wireType == protoWireBytes:
+			b, err := d.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			record.Message = string(b)
+		case (num == protoRecordCaller || num == protoRecordStack) && wireType == protoWireBytes:
+			b, err := d.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			frame, err := decodeProtoFrame(b)
+			if err != nil {
+				return nil, err
+			}
+			if num == protoRecordCaller {
+				record.Caller = &frame
+			} else {
+				record.Stack = append(record.Stack, frame)
+			}
+		default:
+			if err := d.skip(wireType); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return record, nil
+}
+
+func decodeProtoFrame(p []byte) (frame ProtoFrame, err error) {
+	d := protoDecoder{p: p}
+	for !d.done() {
+		num, wireType, err := d.readTag()
+		if err != nil {
+			return frame, err
+		}
+		switch {
+		case (num == protoFrameFile || num == protoFrameFunction) && wireType == protoWireBytes:
+			b, err := d.readBytes()
+			if err != nil {
+				return frame, err
+			}
+			if num == protoFrameFile {
+				frame.File = string(b)
+			} else {
+				frame.Function = string(b)
+			}
+		case num == protoFrameLine && wireType == protoWireVarint:
+			v, err := d.readVarint()
+			if err != nil {
+				return frame, err
+			}
+			frame.Line = int(v)
+		default:
+			if err := d.skip(wireType); err != nil {
+				return frame, err
+			}
+		}
+	}
+	return frame, nil
+}
+
+func decodeProtoField(p []byte, depth int) (field ProtoField, err error) {
+	if depth > protoMaxDepth {
+		return field, errProtoMaxDepth
+	}
+	d := protoDecoder{p: p}
+	for !d.done() {
+		num, wireType, err := d.readTag()
+		if err != nil {
+			return field, err
+		}
+		switch wireType {
+		case protoWireVarint:
+			v, err := d.readVarint()
+			if err != nil {
+				return field, err
+			}
+			switch num {
+			case protoFieldInt:
+				field.Value = int64(v>>1) ^ -int64(v&1)
+			case protoFieldUint:
+				field.Value = v
+			case protoFieldBool:
+				field.Value = v != 0
+			case protoFieldDuration:
+				field.Value = time.Duration(int64(v>>1) ^ -int64(v&1))
+			}
+		case protoWireFixed64:
+			v, err := d.readFixed64()
+			if err != nil {
+				return field, err
+			}
+			switch num {
+			case protoFieldFloat:
+				field.Value = math.Float64frombits(v)
+			case protoFieldTime:
+				field.Value = time.Unix(0, int64(v))
+			}
+		case protoWireBytes:
+			b, err := d.readBytes()
+			if err != nil {
+				return field, err
+			}
+			switch num {
+			case protoFieldKey:
+				field.Key = string(b)
+			case protoFieldString:
+				field.Value = string(b)
+			case protoFieldRaw:
+				field.Value = append([]byte(nil), b...)
+			case protoFieldArray, protoFieldObject:
+				fields, err := decodeProtoFields(b, depth+1)
+				if err != nil {
+					return field, err
+				}
+				if num == protoFieldObject {
+					field.Value = fields
+				} else {
+					values := make([]interface{}, len(fields))
+					for i := range fields {
+						values[i] = fields[i].Value
+					}
+					field.Value = values
+				}
+			case protoFieldComplex:
+				c, err := decodeProtoComplex(b)
+				if err != nil {
+					return field, err
+				}
+				field.Value = c
+			}
+		default:
+			if err := d.skip(wireType); err != nil {
+				return field, err
+			}
+		}
+	}
+	return field, nil
+}
+
+func decodeProtoFields(p []byte, depth int) ([]ProtoField, error) {
+	fields := make([]ProtoField, 0)
+	d := protoDecoder{p: p}
+	for !d.done() {
+		num, wireType, err := d.readTag()
+		if err != nil {
+			return nil, err
+		}
+		if num != protoFieldsFields || wireType != protoWireBytes {
+			if err := d.skip(wireType); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		b, err := d.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		field, err := decodeProtoField(b, depth)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func decodeProtoComplex(p []byte) (complex128, error) {
+	var re, im float64
+	d := protoDecoder{p: p}
+	for !d.done() {
+		num, wireType, err := d.readTag()
+		if err != nil {
+			return 0, err
+		}
+		if wireType != protoWireFixed64 || (num != protoComplexReal && num != protoComplexImag) {
+			if err := d.skip(wireType); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		v, err := d.readFixed64()
+		if err != nil {
+			return 0, err
+		}
+		if num == protoComplexReal {
+			re = math.Float64frombits(v)
+		} else {
+			im = math.Float64frombits(v)
+		}
+	}
+	return complex(re, im), nil
+}
+
+// protoDecoder reads the protocol buffers wire format from bytes.
+type protoDecoder struct {
+	p   []byte
+	off int
+}
+
+func (d *protoDecoder) done() bool { return d.off >= len(d.p) }
+
+func (d *protoDecoder) readVarint() (uint64, error) {
+	v, n := binary.Uvarint(d.p[d.off:])
+	if n <= 0 {
+		return 0, errProtoTruncated
+	}
+	d.off += n
+	return v, nil
+}
+
+func (d *protoDecoder) readSint64() (int64, error) {
+	v, err := d.readVarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (d *protoDecoder) readTag() (num int, wireType int, err error) {
+	v, err := d.readVarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(v >> 3), int(v & 7), nil
+}
+
+func (d *protoDecoder) readFixed64() (uint64, error) {
+	if len(d.p)-d.off < 8 {
+		return 0, errProtoTruncated
+	}
+	v := binary.LittleEndian.Uint64(d.p[d.off:])
+	d.off += 8
+	return v, nil
+}
+
+func (d *protoDecoder) readBytes() ([]byte, error) {
+	n, err := d.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.p)-d.off) {
+		return nil, errProtoTruncated
+	}
+	b := d.p[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// skip skips the value of unknown field.
+func (d *protoDecoder) skip(wireType int) error {
+	var err error
+	switch wireType {
+	case protoWireVarint:
+		_, err = d.readVarint()
+	case protoWireFixed64:
+		_, err = d.readFixed64()
+	case protoWireBytes:
+		_, err = d.readBytes()
+	case protoWireFixed32:
+		if len(d.p)-d.off < 4 {
+			return errProtoTruncated
+		}
+		d.off += 4
+	default:
+		err = fmt.Errorf("glog: proto unsupported wire type %d", wireType)
+	}
+	return err
+}
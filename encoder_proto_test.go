@@ -0,0 +1,203 @@
+package glog
+
+import (
+	"bytes"
+	hexenc "encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtoEncoder_AddObject(t *testing.T) {
+	enc := ProtoEncoder()
+	defer func() {
+		_ = enc.Close()
+	}()
+
+	tm := time.Date(2020, 11, 4, 18, 6, 13, 0, time.UTC)
+	var infos infos
+	infos = append(infos, &info{
+		Name:  "aa",
+		Sex:   "man",
+		Age:   999,
+		Times: timeArray{tm, tm},
+	})
+	infos = append(infos, &info{
+		Name:  "bb",
+		Sex:   "man",
+		Age:   -999,
+		Times: timeArray{},
+	})
+
+	enc.AddBeginMarker()
+	require.Nil(t, enc.AddArray("infos", infos))
+	enc.AddEndMarker()
+
+	record, err := NewProtoReader(bytes.NewReader(enc.Bytes())).Next()
+	require.Nil(t, err)
+	require.Equal(t, []ProtoField{{
+		Key: "infos",
+		Value: []interface{}{
+			[]ProtoField{
+				{Key: "name", Value: "aa"},
+				{Key: "sex", Value: "man"},
+				{Key: "age", Value: int64(999)},
+				{Key: "times", Value: []interface{}{time.Unix(0, tm.UnixNano()), time.Unix(0, tm.UnixNano())}},
+			},
+			[]ProtoField{
+				{Key: "name", Value: "bb"},
+				{Key: "sex", Value: "man"},
+				{Key: "age", Value: int64(-999)},
+				{Key: "times", Value: []interface{}{}},
+			},
+		},
+	}}, record.Fields)
+}
+
+func TestProtoEncoder_Wire(t *testing.T) {
+	enc := ProtoEncoder()
+	defer func() {
+		_ = enc.Close()
+	}()
+
+	enc.AddBeginMarker()
+	enc.AddLevel(TraceLevel)
+	enc.AddMsg("hi")
+	enc.AddInt64("a", -2)
+	enc.AddEndMarker()
+	enc.AddLineBreak()
+
+	// len=16, level(3)=zigzag(-1), message(4)="hi", fields(1)={key(1)="a", int(2)=zigzag(-2)}.
+	// The lengths are the varints padded to 4 bytes.
+	require.Equal(t, "90808000"+"1801"+"22026869"+"0a85808000"+"0a0161"+"1003", hexenc.EncodeToString(enc.Bytes()))
+}
+
+func TestProtoEncoder_WithLogger(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithEncoderFunc(ProtoEncoder).WithExporter(StandardExporter(&b)).
+		WithCaller(true).WithCallerFunc(true)
+	l.WithFields().AddString("rid", "xxxxxx01")
+
+	tm := time.Unix(1604484373, 151354000)
+	for i := 0; i < 3; i++ {
+		l.Info().
+			Msg("test proto").
+			Int("Index", i).
+			String("String", "Value \"quoted\"\n").
+			Strings("Strings", []string{"a", "b"}).
+			Byte("Byte", 'a').
+			Bool("Bool", true).
+			Int64("Int64", math.MinInt64).
+			Uint64("Uint64", math.MaxUint64).
+			Float64("Float64", 99.99).
+			Complex128("Complex128", 1+2i).
+			Millisecond("Duration", -time.Millisecond*1500).
+			Time("Time", tm, TimeFormatUnixSecond).
+			RawBytes("RawBytes", []byte(`{"a":[1,2]}`)).
+			Any("Interface", map[string]int{"x": 1}).
+			Any("Nil", nil).
+			Error("Error", errors.New("failed")).
+			Fire()
+	}
+	l.Warn().Msg(bigMessage(1 << 10)).Fire()
+
+	r := NewProtoReader(&b)
+	for i := 0; i < 3; i++ {
+		record, err := r.Next()
+		require.Nil(t, err)
+		require.Equal(t, InfoLevel, record.Level)
+		require.Equal(t, "test proto", record.Message)
+		require.WithinDuration(t, time.Now(), record.Time, time.Minute)
+		require.NotNil(t, record.Caller)
+		require.Contains(t, record.Caller.File, "encoder_proto_test.go")
+		require.Greater(t, record.Caller.Line, 0)
+		require.Equal(t, "github.com/yu31/glog.TestProtoEncoder_WithLogger", record.Caller.Function)
+
+		require.Equal(t, []ProtoField{
+			{Key: "Index", Value: int64(i)},
+			{Key: "String", Value: "Value \"quoted\"\n"},
+			{Key: "Strings", Value: []interface{}{"a", "b"}},
+			{Key: "Byte", Value: uint64('a')},
+			{Key: "Bool", Value: true},
+			{Key: "Int64", Value: int64(math.MinInt64)},
+			{Key: "Uint64", Value: uint64(math.MaxUint64)},
+			{Key: "Float64", Value: 99.99},
+			{Key: "Complex128", Value: 1 + 2i},
+			{Key: "Duration", Value: -time.Millisecond * 1500},
+			{Key: "Time", Value: time.Unix(0, tm.UnixNano())},
+			{Key: "RawBytes", Value: []byte(`{"a":[1,2]}`)},
+			{Key: "Interface", Value: []byte(`{"x":1}`)},
+			{Key: "Nil", Value: nil},
+			{Key: "Error", Value: []ProtoField{{Key: "msg", Value: "failed"}, {Key: "type", Value: "*errors.errorString"}}},
+			{Key: "rid", Value: "xxxxxx01"},
+		}, record.Fields)
+	}
+
+	record, err := r.Next()
+	require.Nil(t, err)
+	require.Equal(t, WarnLevel, record.Level)
+	require.Equal(t, bigMessage(1<<10), record.Message)
+
+	_, err = r.Next()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestProtoReader_Invalid(t *testing.T) {
+	for _, in := range []string{
+		"02",         // Truncated frame.
+		"0580",       // Truncated frame.
+		"80",         // Truncated length.
+		"03180122",   // Truncated length of message.
+		"02220a",     // The message is longer than frame.
+		"020a03",     // The field is longer than frame.
+		"021880",     // Truncated varint.
+		"0311000000", // Truncated fixed64.
+		"0107",       // Unsupported wire type.
+	} {
+		bs, err := hexenc.DecodeString(in)
+		require.Nil(t, err)
+		_, err = NewProtoReader(bytes.NewReader(bs)).Next()
+		require.NotNil(t, err, in)
+	}
+
+	bs, _ := hexenc.DecodeString("031801")
+	_, err := NewProtoReader(bytes.NewReader(bs)).Next()
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+
+	// Unknown fields are skipped.
+	bs, _ = hexenc.DecodeString("0c" + "f80101" + "fd0100000000" + "220161")
+	record, err := NewProtoReader(bytes.NewReader(bs)).Next()
+	require.Nil(t, err)
+	require.Equal(t, "a", record.Message)
+
+	// Record size.
+	bs, _ = hexenc.DecodeString("ffffffff0f")
+	_, err = NewProtoReader(bytes.NewReader(bs)).Next()
+	require.Equal(t, errProtoTooLarge, err)
+
+	// Nesting depth.
+	enc := newProtoEncoder()
+	defer func() {
+		_ = enc.Close()
+	}()
+	enc.AddBeginMarker()
+	starts := make([]int, 0, protoMaxDepth+2)
+	starts = append(starts, enc.beginField("k"))
+	for i := 0; i < protoMaxDepth+1; i++ {
+		starts = append(starts, enc.beginMessage(protoFieldArray), enc.beginElement())
+	}
+	for i := len(starts) - 1; i >= 0; i-- {
+		enc.endMessage(starts[i])
+	}
+	enc.AddEndMarker()
+	_, err = NewProtoReader(bytes.NewReader(enc.Bytes())).Next()
+	require.Equal(t, errProtoMaxDepth, err)
+}
+
+func bigMessage(n int) string {
+	return string(bytes.Repeat([]byte("x"), n))
+}
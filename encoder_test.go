@@ -1,6 +1,14 @@
 package glog
 
-import "time"
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
 
 type timeArray []time.Time
 
@@ -36,3 +44,40 @@ func (vv infos) MarshalGLogArray(ae ArrayEncoder) error {
 	}
 	return nil
 }
+
+func TestEncoder_Complex(t *testing.T) {
+	cases := []struct {
+		c        complex128
+		expected string
+	}{
+		{1 + 2i, "1+2i"},
+		{1 - 2i, "1-2i"},
+		{complex(-1, math.Copysign(0, -1)), "-1-0i"},
+	}
+	encoders := []struct {
+		name   string
+		f      EncoderFunc
+		format string
+	}{
+		{"json", JSONEncoder, `"c":"%s"`},
+		{"text", TextEncoder, "c=%s"},
+		{"console", ConsoleEncoderFunc(false), "c=%s"},
+		{"logfmt", LogfmtEncoder, "c=%s"},
+		{"cbor", CBOREncoder, `"c":"%s"`},
+	}
+	// Every encoder formats the complex numbers in the same way.
+	for _, e := range encoders {
+		for _, c := range cases {
+			var b bytes.Buffer
+			l := NewDefault().WithEncoderFunc(e.f).WithExporter(StandardExporter(&b))
+			l.Info().Complex128("c", c.c).Fire()
+			s := b.String()
+			if e.name == "cbor" {
+				var out bytes.Buffer
+				require.Nil(t, CBORToJSON(&out, &b))
+				s = out.String()
+			}
+			require.Contains(t, s, fmt.Sprintf(e.format, c.expected), e.name)
+		}
+	}
+}
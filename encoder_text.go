@@ -9,7 +9,10 @@ import (
 	"github.com/yu31/glog/pkg/buffer"
 )
 
-var _ Encoder = (*textEncoder)(nil)
+var (
+	_ Encoder      = (*textEncoder)(nil)
+	_ StackEncoder = (*textEncoder)(nil)
+)
 
 var _textBufferPool = buffer.NewPool()
 
@@ -55,18 +58,36 @@ func (enc *textEncoder) AddLevel(level Level) {
 	enc.appendString(level.String())
 	enc.buf.AppendByte(']')
 }
-func (enc *textEncoder) AddCaller(skip int) {
-	_, file, line, ok := runtime.Caller(skip + 1)
-	if !ok {
-		return
-	}
+func (enc *textEncoder) AddCaller(frame runtime.Frame, layout int8) {
 	enc.appendElementSeparator()
 	enc.buf.AppendByte('(')
-	enc.appendString(file)
+	enc.appendString(CallerFile(frame.File, layout))
 	enc.buf.AppendByte(':')
-	enc.buf.AppendInt(int64(line))
+	enc.buf.AppendInt(int64(frame.Line))
+	if frame.Function != "" {
+		enc.buf.AppendByte(' ')
+		enc.appendString(CallerFunc(frame.Function, layout))
+	}
 	enc.buf.AppendByte(')')
-
+}
+func (enc *textEncoder) AddStack(pcs []uintptr) {
+	if len(pcs) == 0 {
+		return
+	}
+	// The stack is written as an indented block below the line.
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		enc.buf.AppendString("\n\t")
+		enc.appendString(frame.Function)
+		enc.buf.AppendString("()\n\t\t")
+		enc.appendString(frame.File)
+		enc.buf.AppendByte(':')
+		enc.buf.AppendInt(int64(frame.Line))
+		if !more {
+			break
+		}
+	}
 }
 func (enc *textEncoder) WriteIn(p []byte) error {
 	if len(p) == 0 {
@@ -242,10 +263,7 @@ func (enc *textEncoder) appendComplex128(c complex128) {
 	r, i := float64(real(c)), float64(imag(c))
 	// Because we're always in a quoted string, we can use strconv without
 	// special-casing NaN and +/-Inf.
-	enc.buf.AppendFloat(r, 64)
-	enc.buf.AppendByte('+')
-	enc.buf.AppendFloat(i, 64)
-	enc.buf.AppendByte('i')
+	appendComplex(enc.buf, r, i)
 }
 
 func (enc *textEncoder) appendArray(am ArrayMarshaler) error {
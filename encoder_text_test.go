@@ -1,6 +1,7 @@
 package glog
 
 import (
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -68,3 +69,23 @@ func TestTextEncoder_AddObject(t *testing.T) {
 	require.Equal(t, strings.Count(s, "sex="), 3)
 	require.Equal(t, strings.Count(s, "age="), 3)
 }
+
+func TestTextEncoder_Complex(t *testing.T) {
+	cases := []struct {
+		c        complex128
+		expected string
+	}{
+		{1 + 2i, "1+2i"},
+		{1 - 2i, "1-2i"},
+		{complex(-1, math.Copysign(0, -1)), "-1-0i"},
+		{complex(1, math.Inf(1)), "1+Infi"},
+		{complex(1, math.Inf(-1)), "1-Infi"},
+		{complex(1, math.NaN()), "1+NaNi"},
+	}
+	for _, c := range cases {
+		enc := TextEncoder()
+		enc.AddComplex128("c", c.c)
+		require.Equal(t, "c="+c.expected, string(enc.Bytes()))
+		require.Nil(t, enc.Close())
+	}
+}
@@ -2,47 +2,110 @@ package glog
 
 import (
 	"fmt"
+	"runtime"
 	"time"
 )
 
 // Entry used to build a log record.
 type Entry struct {
-	level   Level
 	encoder Encoder
+	stack   bool
+	// mute indicates the entry is below the level and not be exported,
+	// it's only used to perform the action of FatalLevel or PanicLevel.
+	mute bool
+
+	// callerSkip is the number of additional stack frames to skip.
+	callerSkip int
+
+	// record keeps the level, time and msg for the sampler, and it's exported
+	// directly since the Entry is never reused.
+	record Record
+
+	// rec captures the fields for Record, nil if WithRecordFields(false).
+	rec *recordEncoder
+	// multi is the encoder for the EncoderExporters in exporter, nil if not required.
+	multi *multiEncoder
 
 	l *Logger
+	// core is the configuration snapshot of l when the entry created.
+	core *loggerCore
 }
 
 // newEntry will create a new entry with level and fields.
-func newEntry(l *Logger, level Level) *Entry {
+func newEntry(l *Logger, core *loggerCore, level Level) *Entry {
 	e := &Entry{
-		level:   level,
-		encoder: l.encoderFunc(),
+		encoder: core.encoderFunc(),
+		record:  Record{level: level},
 
-		l: l,
+		l:    l,
+		core: core,
+	}
+	if len(core.encoders) != 0 {
+		e.multi = newMultiEncoder(e.encoder, core.encoders)
+		e.encoder = e.multi
+	}
+	if core.recordFields {
+		e.rec = &recordEncoder{Encoder: e.encoder}
+		e.encoder = e.rec
 	}
 	e.encodeHeads()
 	return e
 }
 
+// callers returns the program counters of current goroutine's stack,
+// the argument skip is the number of stack frames to skip before recording,
+// with 0 identifying the caller of callers.
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, 32)
+	for {
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			return pcs[:n]
+		}
+		pcs = make([]uintptr, len(pcs)*2)
+	}
+}
+
 // withError handle any error if happen in entry inside
 func (e *Entry) withError(err error) {
 	if err == nil {
 		return
 	}
-	_, _ = fmt.Fprintf(e.l.errorOutput, "[glog] %s handle log entry error: %v\n", time.Now().Format(e.l.timeLayout), err)
+	_, _ = fmt.Fprintf(e.core.errorOutput, "[glog] %s handle log entry error: %v\n", time.Now().Format(e.core.timeLayout), err)
 }
 
 func (e *Entry) encodeHeads() {
 	e.encoder.AddBeginMarker()
-	e.encoder.AddEntryTime(time.Now(), e.l.timeLayout)
-	e.encoder.AddLevel(e.level)
+	e.record.time = time.Now()
+	e.encoder.AddEntryTime(e.record.time, e.core.timeLayout)
+	e.encoder.AddLevel(e.record.level)
 }
 
+// encodeEnds encodes the fields of the end, and sets the caller into the record.
 func (e *Entry) encodeEnds() {
-	e.withError(e.encoder.WriteIn(e.l.fields.Bytes()))
-	if e.l.caller {
-		e.encoder.AddCaller(2)
+	if e.core.name != "" {
+		e.encoder.AddString(loggerNameKey, e.core.name)
+	}
+	e.withError(e.encoder.WriteIn(e.core.fields))
+	if e.multi != nil {
+		e.withError(e.multi.addFields(e.core.fixedFields))
+	}
+	// Skip the frames of encodeEnds, fire and Fire.
+	skip := 3 + e.core.callerSkip + e.callerSkip
+	if e.core.caller {
+		if frame, ok := callerFrame(skip); ok {
+			if !e.core.callerFunc {
+				frame.Function = ""
+			}
+			ext := e.record.extension()
+			ext.caller, ext.hasCaller = frame, true
+			e.encoder.AddCaller(frame, e.core.callerFormat)
+		}
+	}
+	if e.stack || (e.core.stack && e.record.level.rank() >= e.core.stackLevel.rank()) {
+		if se, ok := e.encoder.(StackEncoder); ok {
+			se.AddStack(callers(skip))
+		}
 	}
 	e.encoder.AddEndMarker()
 	e.encoder.AddLineBreak()
@@ -53,8 +116,6 @@ func (e *Entry) free() {
 		return
 	}
 	e.withError(e.encoder.Close())
-	e.l = nil
-	e.encoder = nil
 }
 
 // Fire sends the *Entry to Logger's exporter.
@@ -65,28 +126,126 @@ func (e *Entry) Fire() {
 	if e == nil {
 		return
 	}
-	e.encodeEnds()
+	e.fire()
+}
+
+// Firef adds the message formatted by fmt.Sprintf and then sends the *Entry
+// to Logger's exporter. The format is evaluated only if the entry is enabled.
+//
+// NOTICE: once this method is called, the *Entry should be disposed.
+func (e *Entry) Firef(format string, v ...interface{}) {
+	if e == nil {
+		return
+	}
+	e.Msg(fmt.Sprintf(format, v...))
+	e.fire()
+}
+
+// fire is the implementation of Fire; it must be called by the exported
+// method directly to keep the depth of caller.
+//
+// The sampler and hooks can only drop the export, the actions of FatalLevel
+// and PanicLevel are always performed.
+func (e *Entry) fire() {
+	export := !e.mute && (e.core.sampler == nil || e.core.sampler.Sample(e.record.level, e.record.msg))
+	if export && len(e.core.hooks) != 0 {
+		export = e.runHooks()
+	}
+	if export {
+		// The Record is built only if the entry be exported.
+		e.encodeEnds()
+
+		// NOTICE: The `data` will be reuse by put back to sync.Pool.
+		// Thus the `*Record` should be disposed after the `Export` returns.
+		e.withError(e.core.exporter.Export(e.fillRecord()))
+	}
 
-	// NOTICE: The `data` will be reuse by put back to sync.Pool.
-	// Thus the `*Record` should be disposed after the `Export` returns.
-	e.withError(e.l.exporter.Export(&Record{
-		ctx:   e.l.ctx,
-		level: e.level,
-		data:  e.encoder.Bytes(),
-	}))
+	c, level, msg := e.core, e.record.level, e.record.msg
 
 	// Release resources
 	e.free()
+
+	switch level {
+	case FatalLevel:
+		// Close the exporter to flush the buffered data before exit.
+		if err := c.exporter.Close(); err != nil {
+			_, _ = fmt.Fprintf(c.errorOutput, "[glog] %s close exporter error before exit: %v\n", time.Now().Format(c.timeLayout), err)
+		}
+		c.exitFunc(1)
+	case PanicLevel:
+		c.panicFunc(msg)
+	}
+}
+
+// fillRecord fills the record with the content of the entry for exporting.
+func (e *Entry) fillRecord() *Record {
+	r := &e.record
+	r.ctx = e.core.ctx
+	r.data = e.encoder.Bytes()
+	if e.rec != nil {
+		ext := r.extension()
+		ext.fields = append(e.rec.fields, e.core.fixedFields...)
+		if ext.fields == nil {
+			ext.fields = []Field{}
+		}
+	}
+	if e.multi != nil {
+		// The alts includes r itself, so that it can be found by the nested EncoderExporter.
+		n := len(e.multi.others) + 1
+		ext := r.extension()
+		ext.keys = make([]*encoderExporter, n)
+		ext.alts = make([]Record, n)
+		ext.alts[0] = *r
+		for i, enc := range e.multi.others {
+			ext.keys[i+1] = e.core.encoders[i]
+			ext.alts[i+1] = *r
+			ext.alts[i+1].data = enc.Bytes()
+		}
+	}
+	return r
 }
 
 func (e *Entry) Msg(msg string) *Entry {
 	if e == nil {
 		return nil
 	}
+	e.record.msg = msg
 	e.encoder.AddMsg(msg)
 	return e
 }
 
+// Msgf adds the message formatted by fmt.Sprintf.
+// The format is evaluated only if the entry is enabled.
+//
+// Notes: the arguments are converted to interface{} by the caller even if
+// the entry is disabled, which allocates for non-constant values.
+func (e *Entry) Msgf(format string, v ...interface{}) *Entry {
+	if e == nil {
+		return nil
+	}
+	return e.Msg(fmt.Sprintf(format, v...))
+}
+
+// Stack adds the stack trace of current goroutine to the entry when fire;
+// It's omitted if the Encoder doesn't implement StackEncoder.
+func (e *Entry) Stack() *Entry {
+	if e == nil {
+		return nil
+	}
+	e.stack = true
+	return e
+}
+
+// CallerSkip adds the number of stack frames to skip when getting the caller
+// and stack trace for this entry, it's in addition to the logger's callerSkip.
+func (e *Entry) CallerSkip(skip int) *Entry {
+	if e == nil {
+		return nil
+	}
+	e.callerSkip += skip
+	return e
+}
+
 // RawBytes adds already serialized data to the log entry under key.
 //
 // No sanity check is performed on bs; it must not contains carriage returns
@@ -445,18 +604,25 @@ func (e *Entry) Hour(k string, d time.Duration) *Entry {
 	return e
 }
 
+// Error encode the error to an object with its message, concrete type and causes,
+// e.g. {"msg":"a: b","type":"*fmt.wrapError","causes":[{"msg":"b","type":"*errors.errorString"}]}.
+//
+// The causes are found by errors.Unwrap or the multi-error interface
+// `Unwrap() []error` and `Errors() []error`. If the error implements
+// ObjectMarshaler, its fields are added instead of the message and type.
 func (e *Entry) Error(k string, err error) *Entry {
 	if e == nil {
 		return nil
 	}
 	if err != nil {
-		e.encoder.AddString(k, err.Error())
+		e.withError(e.encoder.AddObject(k, errorObject{err: err}))
 	} else {
 		e.encoder.AddString(k, "<nil>")
 	}
 	return e
 }
 
+// Errors encode the errors to an array of objects same as Error.
 func (e *Entry) Errors(k string, errs []error) *Entry {
 	if e == nil {
 		return nil
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
@@ -21,11 +23,11 @@ func TestEntry_newEntry(t *testing.T) {
 
 	l := NewDefault().WithContext(ctx)
 
-	entry := newEntry(l, DebugLevel)
+	entry := newEntry(l, l.load(), DebugLevel)
 	require.NotNil(t, entry)
 	require.Equal(t, l, entry.l)
 	require.NotNil(t, entry.encoder)
-	require.Equal(t, DebugLevel, entry.level)
+	require.Equal(t, DebugLevel, entry.record.level)
 }
 
 func TestEntry_Byte_WithText(t *testing.T) {
@@ -283,3 +285,228 @@ func TestEntry_Errors(t *testing.T) {
 	})
 
 }
+
+func TestEntry_Msgf(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithCaller(true)
+
+	_, file, line, ok := runtime.Caller(0)
+	require.True(t, ok)
+	l.Info().Msgf("Hello %s %d", "World", 1).Fire()
+	l.Info().Firef("Hello %s %d", "World", 2)
+
+	s := b.String()
+	require.Contains(t, s, "[info] Hello World 1 ("+file+":"+strconv.Itoa(line+2)+")")
+	require.Contains(t, s, "[info] Hello World 2 ("+file+":"+strconv.Itoa(line+3)+")")
+
+	// The disabled entry does not format.
+	b.Reset()
+	l.WithLevel(ErrorLevel)
+	l.Info().Msgf("%v", formatPanic{}).Fire()
+	l.Info().Firef("%v", formatPanic{})
+	require.Equal(t, 0, b.Len())
+}
+
+type formatPanic struct{}
+
+func (formatPanic) String() string { panic("must not be formatted") }
+
+func TestEntry_Stack(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		var b bytes.Buffer
+		l := NewDefault().WithExporter(StandardExporter(&b)).WithEncoderFunc(JSONEncoder)
+
+		l.Info().Msg("HelloWorld").Fire()
+		require.NotContains(t, b.String(), `"stack"`)
+
+		b.Reset()
+		l.Info().Msg("with stack").Stack().Fire()
+
+		var m struct {
+			Stack []struct {
+				Func string `json:"func"`
+				File string `json:"file"`
+				Line int    `json:"line"`
+			} `json:"stack"`
+		}
+		require.Nil(t, json.Unmarshal(b.Bytes(), &m), b.String())
+		require.Greater(t, len(m.Stack), 1)
+		require.Equal(t, "github.com/yu31/glog.TestEntry_Stack.func1", m.Stack[0].Func)
+		require.True(t, strings.HasSuffix(m.Stack[0].File, "entry_test.go"), m.Stack[0].File)
+		require.Greater(t, m.Stack[0].Line, 0)
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		var b bytes.Buffer
+		l := NewDefault().WithExporter(StandardExporter(&b))
+
+		l.Info().Msg("with stack").String("k", "v").Stack().Fire()
+
+		lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+		require.Greater(t, len(lines), 3)
+		require.True(t, strings.HasSuffix(lines[0], "with stack k=v"), lines[0])
+		require.Equal(t, "\tgithub.com/yu31/glog.TestEntry_Stack.func2()", lines[1])
+		require.True(t, strings.HasPrefix(lines[2], "\t\t"), lines[2])
+		require.Contains(t, lines[2], "entry_test.go:")
+	})
+
+	t.Run("StackLevel", func(t *testing.T) {
+		var b bytes.Buffer
+		l := NewDefault().WithExporter(StandardExporter(&b)).WithEncoderFunc(JSONEncoder)
+
+		// The stack level takes effect only if WithStack(true).
+		l.Error().Msg("error message").Fire()
+		require.NotContains(t, b.String(), `"stack"`)
+
+		l.WithStack(true)
+		l.Warn().Msg("warn message").Fire()
+		require.NotContains(t, b.String(), `"stack"`)
+
+		l.Error().Msg("error message").Fire()
+		require.Contains(t, b.String(), `"stack":[{"func":"github.com/yu31/glog.TestEntry_Stack.func3"`)
+
+		b.Reset()
+		l.WithStackLevel(WarnLevel)
+		l.Warn().Msg("warn message").Fire()
+		require.Contains(t, b.String(), `"stack":[{"func":"github.com/yu31/glog.TestEntry_Stack.func3"`)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		encoders := map[string]EncoderFunc{
+			"JSON":    JSONEncoder,
+			"Text":    TextEncoder,
+			"Logfmt":  LogfmtEncoder,
+			"Console": ConsoleEncoder,
+			"CBOR":    CBOREncoder,
+			"Proto":   ProtoEncoder,
+			"ECS":     ECSEncoder,
+		}
+		for name, f := range encoders {
+			enc := f()
+			enc.AddBeginMarker()
+			enc.AddMsg("m")
+			before := string(enc.Bytes())
+			enc.(StackEncoder).AddStack(nil)
+			require.Equal(t, before, string(enc.Bytes()), name)
+			require.Nil(t, enc.Close())
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		var b bytes.Buffer
+		l := NewDefault().WithExporter(StandardExporter(&b)).
+			WithEncoderFunc(func() Encoder { return noStackEncoder{JSONEncoder()} })
+
+		l.Info().Msg("with stack").Stack().Fire()
+		require.Contains(t, b.String(), `"message":"with stack"`)
+		require.NotContains(t, b.String(), `"stack":`)
+	})
+}
+
+// noStackEncoder is an Encoder that doesn't implement StackEncoder.
+type noStackEncoder struct {
+	Encoder
+}
+
+type entryMultiError []error
+
+func (m entryMultiError) Error() string {
+	var ss []string
+	for _, err := range m {
+		ss = append(ss, err.Error())
+	}
+	return strings.Join(ss, "; ")
+}
+
+func (m entryMultiError) Unwrap() []error { return m }
+
+type entryCodeError struct {
+	code int
+}
+
+func (e *entryCodeError) Error() string { return "code " + strconv.Itoa(e.code) }
+
+func (e *entryCodeError) MarshalGLogObject(oe ObjectEncoder) error {
+	oe.AddInt64("code", int64(e.code))
+	return nil
+}
+
+func TestEntry_Error_Chain(t *testing.T) {
+	type errObj struct {
+		Msg    string   `json:"msg"`
+		Type   string   `json:"type"`
+		Code   int      `json:"code"`
+		Causes []errObj `json:"causes"`
+	}
+
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithEncoderFunc(JSONEncoder)
+
+	parse := func() errObj {
+		var m struct {
+			Error errObj `json:"error"`
+		}
+		require.Nil(t, json.Unmarshal(b.Bytes(), &m), b.String())
+		b.Reset()
+		return m.Error
+	}
+
+	// Single error.
+	l.Error().Error("error", errors.New("base")).Fire()
+	require.Equal(t, errObj{Msg: "base", Type: "*errors.errorString"}, parse())
+
+	// Wrapped chain are flattened.
+	base := &entryCodeError{code: 500}
+	err := fmt.Errorf("l1: %w", fmt.Errorf("l2: %w", base))
+	l.Error().Error("error", err).Fire()
+	require.Equal(t, errObj{
+		Msg:  "l1: l2: code 500",
+		Type: "*fmt.wrapError",
+		Causes: []errObj{
+			{Msg: "l2: code 500", Type: "*fmt.wrapError"},
+			// The ObjectMarshaler replaces the msg and type.
+			{Code: 500},
+		},
+	}, parse())
+
+	// Multi-error in chain.
+	err = fmt.Errorf("wrap: %w", entryMultiError{errors.New("e1"), fmt.Errorf("e2: %w", base)})
+	l.Error().Error("error", err).Fire()
+	require.Equal(t, errObj{
+		Msg:  "wrap: e1; e2: code 500",
+		Type: "*fmt.wrapError",
+		Causes: []errObj{
+			{
+				Msg:  "e1; e2: code 500",
+				Type: "glog.entryMultiError",
+				Causes: []errObj{
+					{Msg: "e1", Type: "*errors.errorString"},
+					{
+						Msg:    "e2: code 500",
+						Type:   "*fmt.wrapError",
+						Causes: []errObj{{Code: 500}},
+					},
+				},
+			},
+		},
+	}, parse())
+
+	// Errors.
+	l.Error().Errors("errors", []error{base, nil}).Fire()
+	var m struct {
+		Errors []interface{} `json:"errors"`
+	}
+	require.Nil(t, json.Unmarshal(b.Bytes(), &m), b.String())
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"code": float64(500)},
+		"<nil>",
+	}, m.Errors)
+}
+
+func TestEntry_Error_WithText(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b))
+
+	l.Error().Error("error", fmt.Errorf("l1: %w", errors.New("base"))).Fire()
+	require.Contains(t, b.String(), "error={msg=l1: base type=*fmt.wrapError causes=[{msg=base type=*errors.errorString}]}")
+}
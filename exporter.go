@@ -116,12 +116,3 @@ func (exp *multipleExporter) Close() error {
 	}
 	return fmt.Errorf("%v", errs)
 }
-
-//// FileExporter is a FilterExporter wrapper that uses a file.
-//func FileExporter(name string, f Filter) (Exporter, error) {
-//	w, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-//	if err != nil {
-//		return nil, err
-//	}
-//	return FilterExporter(w, f), nil
-//}
@@ -0,0 +1,150 @@
+package glog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultAsyncQueueSize = 1024
+
+var errAsyncExporterClosed = errors.New("glog: export to a closed async exporter")
+
+var _ Exporter = (*AsyncExporter)(nil)
+
+// DropPolicy declares the behavior of AsyncExporter when its queue is full.
+type DropPolicy int8
+
+const (
+	// DropPolicyBlock blocks the caller until the queue has free space.
+	DropPolicyBlock DropPolicy = iota
+	// DropPolicyNewest drops the record being exported.
+	DropPolicyNewest
+	// DropPolicyOldest drops the oldest record in queue to make room for the new one.
+	DropPolicyOldest
+)
+
+// AsyncConfig declares the options for AsyncExporter.
+type AsyncConfig struct {
+	// QueueSize is the maximum number of records buffered in queue.
+	// Defaults to 1024 if not positive.
+	QueueSize int
+
+	// Policy used to handle the record while the queue is full.
+	// Defaults to DropPolicyBlock.
+	Policy DropPolicy
+
+	// ErrorOutput used to write the error returned by the underlying exporter.
+	// Defaults to os.Stderr.
+	ErrorOutput io.Writer
+}
+
+// AsyncExporter is an Exporter wrapper that copies the records into a bounded
+// queue and exports them to the underlying exporter on a background goroutine.
+type AsyncExporter struct {
+	exporter    Exporter
+	policy      DropPolicy
+	errorOutput io.Writer
+
+	// mu protects the queue from being closed while sending.
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *Record
+	done   chan struct{}
+
+	dropped uint64
+}
+
+// NewAsyncExporter returns an AsyncExporter that wraps the exporter.
+// The cfg can be nil, which means use the default options.
+func NewAsyncExporter(exporter Exporter, cfg *AsyncConfig) *AsyncExporter {
+	var c AsyncConfig
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultAsyncQueueSize
+	}
+	if c.ErrorOutput == nil {
+		c.ErrorOutput = os.Stderr
+	}
+
+	exp := &AsyncExporter{
+		exporter:    exporter,
+		policy:      c.Policy,
+		errorOutput: c.ErrorOutput,
+		queue:       make(chan *Record, c.QueueSize),
+		done:        make(chan struct{}),
+	}
+	go exp.run()
+	return exp
+}
+
+// Export copies the record and puts it into queue.
+func (exp *AsyncExporter) Export(record *Record) error {
+	exp.mu.RLock()
+	defer exp.mu.RUnlock()
+
+	if exp.closed {
+		return errAsyncExporterClosed
+	}
+
+	r := record.clone()
+	switch exp.policy {
+	case DropPolicyNewest:
+		select {
+		case exp.queue <- r:
+		default:
+			atomic.AddUint64(&exp.dropped, 1)
+		}
+	case DropPolicyOldest:
+		for {
+			select {
+			case exp.queue <- r:
+				return nil
+			default:
+			}
+			select {
+			case <-exp.queue:
+				atomic.AddUint64(&exp.dropped, 1)
+			default:
+			}
+		}
+	default:
+		exp.queue <- r
+	}
+	return nil
+}
+
+// Dropped returns the number of records dropped because of the queue is full.
+func (exp *AsyncExporter) Dropped() uint64 {
+	return atomic.LoadUint64(&exp.dropped)
+}
+
+// Close exports all records remaining in queue and then close the underlying exporter.
+func (exp *AsyncExporter) Close() error {
+	exp.mu.Lock()
+	if exp.closed {
+		exp.mu.Unlock()
+		return nil
+	}
+	exp.closed = true
+	close(exp.queue)
+	exp.mu.Unlock()
+
+	<-exp.done
+	return exp.exporter.Close()
+}
+
+func (exp *AsyncExporter) run() {
+	defer close(exp.done)
+	for r := range exp.queue {
+		if err := exp.exporter.Export(r); err != nil {
+			_, _ = fmt.Fprintf(exp.errorOutput, "[glog] %s async export error: %v\n", time.Now().Format(defaultTimeLayout), err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package glog
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// blockingExporter blocks the Export until release is closed.
+type blockingExporter struct {
+	mu       sync.Mutex
+	started  chan struct{}
+	release  chan struct{}
+	once     sync.Once
+	records  []string
+	isClosed bool
+}
+
+func newBlockingExporter() *blockingExporter {
+	return &blockingExporter{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (exp *blockingExporter) Export(record *Record) error {
+	exp.once.Do(func() { close(exp.started) })
+	<-exp.release
+	exp.mu.Lock()
+	exp.records = append(exp.records, string(record.Bytes()))
+	exp.mu.Unlock()
+	return nil
+}
+
+func (exp *blockingExporter) Close() error {
+	exp.isClosed = true
+	return nil
+}
+
+func TestAsyncExporter(t *testing.T) {
+	var b bytes.Buffer
+	exp := NewAsyncExporter(StandardExporter(&b), nil)
+
+	l := NewDefault().WithExporter(exp)
+	for i := 0; i < 100; i++ {
+		l.Info().Msg("HelloWorld").Int("i", i).Fire()
+	}
+	require.Nil(t, l.Close())
+
+	s := b.String()
+	require.Equal(t, 100, strings.Count(s, "HelloWorld"))
+	require.Contains(t, s, "i=0")
+	require.Contains(t, s, "i=99")
+	require.Equal(t, uint64(0), exp.Dropped())
+
+	// Export after close.
+	require.Equal(t, errAsyncExporterClosed, exp.Export(&Record{}))
+	require.Nil(t, exp.Close())
+}
+
+func TestAsyncExporter_DropPolicy(t *testing.T) {
+	run := func(policy DropPolicy) ([]string, uint64) {
+		inner := newBlockingExporter()
+		exp := NewAsyncExporter(inner, &AsyncConfig{QueueSize: 2, Policy: policy})
+
+		// The first record is taken by background goroutine and blocked.
+		require.Nil(t, exp.Export(&Record{data: []byte("0")}))
+		<-inner.started
+
+		for i := 1; i <= 4; i++ {
+			require.Nil(t, exp.Export(&Record{data: []byte(strconv.Itoa(i))}))
+		}
+		close(inner.release)
+		require.Nil(t, exp.Close())
+		require.True(t, inner.isClosed)
+		return inner.records, exp.Dropped()
+	}
+
+	records, dropped := run(DropPolicyNewest)
+	require.Equal(t, []string{"0", "1", "2"}, records)
+	require.Equal(t, uint64(2), dropped)
+
+	records, dropped = run(DropPolicyOldest)
+	require.Equal(t, []string{"0", "3", "4"}, records)
+	require.Equal(t, uint64(2), dropped)
+}
+
+func TestAsyncExporter_Block(t *testing.T) {
+	inner := newBlockingExporter()
+	exp := NewAsyncExporter(inner, &AsyncConfig{QueueSize: 1, Policy: DropPolicyBlock})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			_ = exp.Export(&Record{data: []byte(strconv.Itoa(i))})
+		}
+	}()
+
+	<-inner.started
+	close(inner.release)
+	wg.Wait()
+	require.Nil(t, exp.Close())
+	require.Equal(t, 10, len(inner.records))
+	require.Equal(t, uint64(0), exp.Dropped())
+}
+
+type errorExporter struct{}
+
+func (exp *errorExporter) Export(record *Record) error { return errors.New("export failed") }
+func (exp *errorExporter) Close() error                { return nil }
+
+func TestAsyncExporter_Error(t *testing.T) {
+	var eb bytes.Buffer
+	exp := NewAsyncExporter(&errorExporter{}, &AsyncConfig{ErrorOutput: &eb})
+	require.Nil(t, exp.Export(&Record{data: []byte("x")}))
+	require.Nil(t, exp.Close())
+	require.Contains(t, eb.String(), "export failed")
+}
@@ -0,0 +1,97 @@
+package glog
+
+var (
+	_ EncodingExporter = (*encoderExporter)(nil)
+	_ EncodingExporter = (*multipleExporter)(nil)
+	_ EncodingExporter = (*AsyncExporter)(nil)
+)
+
+// EncodingExporter is an Exporter that contains the exporters returned by
+// EncoderExporter, the Logger encodes each entry once more per EncoderExporter;
+// It should be implemented by the Exporter that wraps other exporters.
+type EncodingExporter interface {
+	Exporter
+
+	// EncoderExporters returns the exporters returned by EncoderExporter
+	// in the exporter and its underlying exporters.
+	EncoderExporters() []Exporter
+}
+
+// EncoderExporter return a Exporter that exports the records encoded by f
+// to the exporter, instead of the EncoderFunc of Logger.
+//
+// e.g. To write JSON to a file and colored text to stdout:
+//
+//	MultipleExporter(EncoderExporter(JSONEncoder, file), EncoderExporter(ConsoleEncoderFor(os.Stdout), stdout))
+//
+// Each entry is always encoded by the EncoderFunc of Logger, even if all the
+// exporters are EncoderExporters, and once more per returned Exporter, even
+// if they have the same EncoderFunc; So the example above encodes each entry
+// three times. Wrap the exporters in one EncoderExporter to share the encoding,
+// e.g. EncoderExporter(JSONEncoder, MultipleExporter(file1, file2)), or set the
+// format of one exporter by WithEncoderFunc instead of EncoderExporter.
+//
+// The raw fields, e.g. added by Entry.RawBytes, are encoded by the Encoder
+// of Logger only, since they are serialized in its format.
+func EncoderExporter(f EncoderFunc, exporter Exporter) Exporter {
+	return &encoderExporter{f: f, exporter: exporter}
+}
+
+type encoderExporter struct {
+	f        EncoderFunc
+	exporter Exporter
+}
+
+// EncoderExporters implements EncodingExporter.
+func (exp *encoderExporter) EncoderExporters() []Exporter {
+	return append([]Exporter{exp}, exporterEncoders(exp.exporter)...)
+}
+
+func (exp *encoderExporter) Export(record *Record) error {
+	return exp.exporter.Export(record.encodedBy(exp))
+}
+
+func (exp *encoderExporter) Close() error {
+	return exp.exporter.Close()
+}
+
+// EncoderExporters implements EncodingExporter.
+func (exp *multipleExporter) EncoderExporters() []Exporter {
+	var exporters []Exporter
+	for i := range exp.exporters {
+		exporters = append(exporters, exporterEncoders(exp.exporters[i])...)
+	}
+	return exporters
+}
+
+// EncoderExporters implements EncodingExporter.
+func (exp *AsyncExporter) EncoderExporters() []Exporter {
+	return exporterEncoders(exp.exporter)
+}
+
+// exporterEncoders returns the EncoderExporters in exporter.
+func exporterEncoders(exporter Exporter) []Exporter {
+	if ee, ok := exporter.(EncodingExporter); ok {
+		return ee.EncoderExporters()
+	}
+	return nil
+}
+
+// encoderExporters returns the distinct EncoderExporters in exporter.
+func encoderExporters(exporter Exporter) []*encoderExporter {
+	var encs []*encoderExporter
+LOOP:
+	for _, exp := range exporterEncoders(exporter) {
+		enc, ok := exp.(*encoderExporter)
+		if !ok || enc.f == nil {
+			continue
+		}
+		for i := range encs {
+			if encs[i] == enc {
+				continue LOOP
+			}
+		}
+		encs = append(encs, enc)
+	}
+	return encs
+}
@@ -0,0 +1,154 @@
+package glog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoderExporter(t *testing.T) {
+	var jb, tb, db bytes.Buffer
+	l := NewDefault().WithCaller(true).WithExporter(MultipleExporter(
+		EncoderExporter(JSONEncoder, StandardExporter(&jb)),
+		EncoderExporter(ConsoleEncoderFunc(false), StandardExporter(&tb)),
+		StandardExporter(&db),
+	))
+	l.WithFields().AddString("service", "api")
+	l.WithFields().AddDuration("timeout", time.Second, DurationFormatSecond)
+
+	l.Info().Msg("hello").Int("code", 200).Strings("tags", []string{"a", "b"}).Fire()
+
+	m := make(map[string]interface{})
+	require.Nil(t, json.Unmarshal(jb.Bytes(), &m), jb.String())
+	require.Equal(t, "hello", m["message"])
+	require.Equal(t, float64(200), m["code"])
+	require.Equal(t, []interface{}{"a", "b"}, m["tags"])
+	require.Equal(t, "api", m["service"])
+	require.Equal(t, "1s", m["timeout"])
+	require.Contains(t, m["caller"], "exporter_encoding_test.go:")
+
+	require.True(t, strings.HasPrefix(tb.String()[strings.Index(tb.String(), " ")+1:], "INF hello"), tb.String())
+	require.Contains(t, tb.String(), "code=200 tags=[a, b] service=api timeout=1s")
+
+	// The exporter without EncoderFunc uses the one of Logger.
+	require.Contains(t, db.String(), "[info] hello code=200 tags=[a b] service=api timeout=1s (")
+}
+
+func TestEncoderExporter_Instance(t *testing.T) {
+	var calls uint64
+	counting := func() Encoder {
+		atomic.AddUint64(&calls, 1)
+		return JSONEncoder()
+	}
+	var b1, b2, b3 bytes.Buffer
+	shared := EncoderExporter(counting, StandardExporter(&b1))
+	l := NewDefault().WithExporter(MultipleExporter(
+		shared,
+		shared,
+		EncoderExporter(counting, StandardExporter(&b2)),
+		StandardExporter(&b3),
+	))
+	// The same instance is encoded once.
+	require.Equal(t, 2, len(l.load().encoders))
+
+	l.Info().Msg("hello").Fire()
+	require.Equal(t, uint64(2), atomic.LoadUint64(&calls))
+	require.Equal(t, 2, strings.Count(b1.String(), `"message":"hello"`))
+	require.Equal(t, 1, strings.Count(b2.String(), `"message":"hello"`))
+	require.Contains(t, b3.String(), "[info] hello")
+
+	// The closures of the same function literal are different encodings.
+	b1.Reset()
+	b2.Reset()
+	l.WithExporter(MultipleExporter(
+		EncoderExporter(JSONEncoderFunc(&JSONEncoderConfig{MessageKey: "msg"}), StandardExporter(&b1)),
+		EncoderExporter(JSONEncoderFunc(&JSONEncoderConfig{MessageKey: "text"}), StandardExporter(&b2)),
+	))
+	l.Info().Msg("hello").Fire()
+	require.Contains(t, b1.String(), `"msg":"hello"`)
+	require.Contains(t, b2.String(), `"text":"hello"`)
+
+	// The plain exporters require nothing.
+	l.WithExporter(MultipleExporter(StandardExporter(&b1), StandardExporter(&b2)))
+	require.Nil(t, l.load().encoders)
+}
+
+func TestEncoderExporter_RawFields(t *testing.T) {
+	var jb, tb bytes.Buffer
+	l := NewDefault().WithExporter(MultipleExporter(
+		EncoderExporter(JSONEncoder, StandardExporter(&jb)),
+		StandardExporter(&tb),
+	))
+	l.WithFields().AddRawString("fixed_raw", "<raw1>")
+	l.WithFields().AddString("service", "api")
+
+	l.Info().Msg("hello").RawBytes("raw", []byte("<raw2>")).Fire()
+
+	// The raw fields are only encoded by the Encoder of Logger.
+	require.True(t, json.Valid(jb.Bytes()), jb.String())
+	require.NotContains(t, jb.String(), "raw")
+	require.Contains(t, jb.String(), `"service":"api"`)
+	require.Contains(t, tb.String(), "[info] hello raw=<raw2> fixed_raw=<raw1> service=api")
+}
+
+func TestEncoderExporter_FieldsBefore(t *testing.T) {
+	var tb, jb bytes.Buffer
+	l := NewDefault()
+	l.WithFields().AddString("service", "api")
+	l = l.With().Fields(func(oe ObjectEncoder) error {
+		oe.AddInt64("shard", 3)
+		return nil
+	}).Logger()
+
+	// The fixed fields added before WithExporter are also exported by the EncoderExporters.
+	l.WithExporter(MultipleExporter(
+		StandardExporter(&tb),
+		EncoderExporter(JSONEncoder, StandardExporter(&jb)),
+	))
+	l.Info().Msg("hello").Fire()
+
+	require.Contains(t, tb.String(), "[info] hello service=api shard=3")
+	require.True(t, json.Valid(jb.Bytes()), jb.String())
+	require.Contains(t, jb.String(), `"service":"api","shard":3`)
+}
+
+func TestEncoderExporter_Nested(t *testing.T) {
+	var jb, tb bytes.Buffer
+	l := NewDefault().WithEncoderFunc(LogfmtEncoder).WithExporter(
+		EncoderExporter(JSONEncoder, MultipleExporter(
+			StandardExporter(&jb),
+			EncoderExporter(TextEncoder, StandardExporter(&tb)),
+		)),
+	)
+	l.Warn().Msg("nested").Fire()
+	require.True(t, json.Valid(jb.Bytes()), jb.String())
+	require.Contains(t, tb.String(), "[warn] nested")
+}
+
+func TestEncoderExporter_Async(t *testing.T) {
+	var jb, tb bytes.Buffer
+	exp := NewAsyncExporter(MultipleExporter(
+		EncoderExporter(JSONEncoder, StandardExporter(&jb)),
+		StandardExporter(&tb),
+	), nil)
+	l := NewDefault().WithExporter(exp).WithRecordFields(true)
+
+	for i := 0; i < 10; i++ {
+		l.Info().Msg("async").Int("i", i).Fire()
+	}
+	require.Nil(t, l.Close())
+
+	lines := strings.Split(strings.TrimSpace(jb.String()), "\n")
+	require.Equal(t, 10, len(lines))
+	for i, line := range lines {
+		m := make(map[string]interface{})
+		require.Nil(t, json.Unmarshal([]byte(line), &m), line)
+		require.Equal(t, float64(i), m["i"])
+	}
+	require.Equal(t, 10, strings.Count(tb.String(), "[info] async"))
+}
@@ -0,0 +1,409 @@
+package glog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// backupTimeLayout is the time format used in the name of rotated files.
+	// Fixed width, so lexical order of backups is the same as time order.
+	backupTimeLayout = "20060102T150405.000000000"
+
+	compressSuffix = ".gz"
+)
+
+var errFileWriterClosed = errors.New("glog: write to a closed file writer")
+
+var _ io.WriteCloser = (*FileWriter)(nil)
+
+// FileConfig declares the rotation options for FileWriter.
+type FileConfig struct {
+	// MaxSize is the maximum size in bytes of the log file before it gets rotated.
+	// Zero means never rotate by size.
+	MaxSize int64
+
+	// Interval is the time interval to rotate the log file; the boundary is
+	// aligned to a multiple of Interval since the zero time. Zero means never
+	// rotate by time.
+	Interval time.Duration
+
+	// MaxBackups is the maximum number of rotated files to retain.
+	// Zero means retain all rotated files.
+	MaxBackups int
+
+	// Compress determines whether the rotated files are compressed with gzip.
+	Compress bool
+
+	// ErrorOutput is used to report the errors that do not prevent writing,
+	// e.g. failed to rotate, compress or remove the backups. nil means os.Stderr.
+	//
+	// NOTICE: It's written by the background goroutine too.
+	ErrorOutput io.Writer
+}
+
+// FileWriter is an io.WriteCloser that writes to the named file and
+// rotates it by size and/or time interval.
+//
+// The rotated file is renamed with a timestamp inserted before its extension,
+// e.g. "app.log" is renamed to "app-20201104T180000.000000000.log".
+// The backups are compressed and removed in a background goroutine.
+type FileWriter struct {
+	mu sync.Mutex
+
+	name string
+	cfg  FileConfig
+
+	file       *os.File
+	size       int64
+	nextRotate time.Time
+	// closed indicates the writer is closed by Close, the file may be nil
+	// after a failed rotation without closed, and it's reopened when writing.
+	closed bool
+
+	// millCh notifies the background goroutine to compress and remove the
+	// backups; millDone is closed after the goroutine exits.
+	millCh   chan struct{}
+	millDone chan struct{}
+
+	// now used to get current time, replaced in tests.
+	now func() time.Time
+}
+
+// NewFileWriter opens the named file in append mode and returns a FileWriter.
+// The cfg can be nil, which means never rotate the file.
+func NewFileWriter(name string, cfg *FileConfig) (*FileWriter, error) {
+	w := &FileWriter{
+		name: name,
+		now:  time.Now,
+	}
+	if cfg != nil {
+		w.cfg = *cfg
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write implements io.Writer. The file will be rotated before writing if necessary.
+//
+// The failure of rotation does not prevent writing, it's reported to
+// the ErrorOutput of FileConfig.
+func (w *FileWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errFileWriterClosed
+	}
+	if w.file != nil && w.shouldRotate(len(p)) {
+		if err = w.rotate(); err != nil {
+			w.reportError(err)
+		}
+	}
+	if w.file == nil {
+		// Reopen the file after a failed rotation.
+		if err = w.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate forces the current file to be rotated.
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return errFileWriterClosed
+	}
+	if w.file == nil {
+		return w.open()
+	}
+	return w.rotate()
+}
+
+// Reopen closes and reopens the file with the same name.
+// This used to work with external rotation tools such as logrotate, and
+// can be used to reopen a closed FileWriter too.
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		w.file = nil
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.closed = false
+	return nil
+}
+
+// Close closes the file, and waits for the background goroutine to
+// finish compressing and removing the backups.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.millCh != nil {
+		close(w.millCh)
+		<-w.millDone
+		w.millCh, w.millDone = nil, nil
+	}
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *FileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.name), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	if w.cfg.Interval > 0 {
+		w.nextRotate = w.now().Truncate(w.cfg.Interval).Add(w.cfg.Interval)
+	}
+	return nil
+}
+
+func (w *FileWriter) shouldRotate(n int) bool {
+	if w.cfg.MaxSize > 0 && w.size > 0 && w.size+int64(n) > w.cfg.MaxSize {
+		return true
+	}
+	if w.cfg.Interval > 0 && !w.now().Before(w.nextRotate) {
+		return true
+	}
+	return false
+}
+
+// rotate renames the current file to a backup and opens a new one. On failure,
+// the original file is reopened if possible, otherwise w.file is nil.
+func (w *FileWriter) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		_ = w.open()
+		return err
+	}
+
+	if w.size > 0 {
+		backup := w.backupName(w.now())
+		if err := os.Rename(w.name, backup); err != nil {
+			_ = w.open()
+			return err
+		}
+		if err := w.open(); err != nil {
+			if os.Rename(backup, w.name) == nil {
+				_ = w.open()
+			}
+			return err
+		}
+	} else if err := w.open(); err != nil {
+		return err
+	}
+
+	if w.cfg.Compress || w.cfg.MaxBackups > 0 {
+		w.startMill()
+		select {
+		case w.millCh <- struct{}{}:
+		default:
+			// The goroutine is already notified.
+		}
+	}
+	return nil
+}
+
+// startMill starts the background goroutine for the backups if not started.
+func (w *FileWriter) startMill() {
+	if w.millCh != nil {
+		return
+	}
+	w.millCh = make(chan struct{}, 1)
+	w.millDone = make(chan struct{})
+	go w.millRun(w.millCh, w.millDone)
+}
+
+func (w *FileWriter) millRun(ch <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for range ch {
+		if err := w.mill(); err != nil {
+			w.reportError(err)
+		}
+	}
+}
+
+// mill compresses the backups if required and removes the old ones.
+func (w *FileWriter) mill() error {
+	if w.cfg.Compress {
+		names, err := w.backups()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if strings.HasSuffix(name, compressSuffix) {
+				continue
+			}
+			if err := compressFile(name); err != nil {
+				return err
+			}
+		}
+	}
+	return w.removeBackups()
+}
+
+// reportError writes the error that does not prevent writing to ErrorOutput.
+func (w *FileWriter) reportError(err error) {
+	out := w.cfg.ErrorOutput
+	if out == nil {
+		out = os.Stderr
+	}
+	_, _ = fmt.Fprintf(out, "[glog] %s file writer %s error: %v\n", time.Now().Format(defaultTimeLayout), w.name, err)
+}
+
+func (w *FileWriter) backupName(t time.Time) string {
+	prefix, ext := w.backupPrefixAndExt()
+	return prefix + t.Format(backupTimeLayout) + ext
+}
+
+func (w *FileWriter) backupPrefixAndExt() (prefix, ext string) {
+	ext = filepath.Ext(w.name)
+	prefix = strings.TrimSuffix(w.name, ext) + "-"
+	return
+}
+
+// backups returns the rotated files sorted from oldest to newest.
+func (w *FileWriter) backups() ([]string, error) {
+	dir := filepath.Dir(w.name)
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix, ext := w.backupPrefixAndExt()
+	prefix = filepath.Base(prefix)
+
+	var names []string
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		name := strings.TrimSuffix(info.Name(), compressSuffix)
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if _, err := time.Parse(backupTimeLayout, ts); err != nil {
+			continue
+		}
+		names = append(names, filepath.Join(dir, info.Name()))
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.TrimSuffix(names[i], compressSuffix) < strings.TrimSuffix(names[j], compressSuffix)
+	})
+	return names, nil
+}
+
+func (w *FileWriter) removeBackups() error {
+	if w.cfg.MaxBackups <= 0 {
+		return nil
+	}
+	names, err := w.backups()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for len(names) > w.cfg.MaxBackups {
+		if err := os.Remove(names[0]); err != nil {
+			errs = append(errs, err)
+		}
+		names = names[1:]
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%v", errs)
+}
+
+// compressFile compresses the file with gzip and removes the source.
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	dst, err := os.OpenFile(name+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(dst)
+	if _, err = io.Copy(gw, src); err != nil {
+		_ = gw.Close()
+		_ = dst.Close()
+		return err
+	}
+	if err = gw.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+// FileExporter is a FilterExporter wrapper that writes to the named file,
+// the file never be rotated. The f can be nil, which means export all levels.
+func FileExporter(name string, f Filter) (Exporter, error) {
+	return RotateFileExporter(name, f, nil)
+}
+
+// RotateFileExporter is a FilterExporter wrapper that writes to the named file,
+// the file be rotated by the cfg. The f can be nil, which means export all levels.
+//
+// Use NewFileWriter directly if the Reopen or Rotate method is needed.
+func RotateFileExporter(name string, f Filter, cfg *FileConfig) (Exporter, error) {
+	w, err := NewFileWriter(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return StandardExporter(w), nil
+	}
+	return FilterExporter(w, f), nil
+}
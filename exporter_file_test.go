@@ -0,0 +1,226 @@
+package glog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileExporter(t *testing.T) {
+	dir := t.TempDir()
+	name1 := filepath.Join(dir, "test.log")
+	name2 := filepath.Join(dir, "test.log.wf")
+
+	e1, err := FileExporter(name1, nil)
+	require.Nil(t, err)
+	e2, err := FileExporter(name2, MatchGTELevel(ErrorLevel))
+	require.Nil(t, err)
+
+	l := NewDefault().WithExporter(MultipleExporter(e1, e2))
+	l.Debug().Msg("DebugMessage").Fire()
+	l.Error().Msg("ErrorMessage").Fire()
+	require.Nil(t, l.Close())
+
+	b1, err := ioutil.ReadFile(name1)
+	require.Nil(t, err)
+	require.Contains(t, string(b1), "DebugMessage")
+	require.Contains(t, string(b1), "ErrorMessage")
+
+	b2, err := ioutil.ReadFile(name2)
+	require.Nil(t, err)
+	require.NotContains(t, string(b2), "DebugMessage")
+	require.Contains(t, string(b2), "ErrorMessage")
+}
+
+func TestFileWriter_RotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "size.log")
+
+	w, err := NewFileWriter(name, &FileConfig{MaxSize: 10, MaxBackups: 2})
+	require.Nil(t, err)
+
+	now := time.Date(2020, 11, 4, 18, 0, 0, 0, time.UTC)
+	w.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err = w.Write([]byte("123456789\n"))
+		require.Nil(t, err)
+	}
+	require.Nil(t, w.Close())
+
+	backups, err := w.backups()
+	require.Nil(t, err)
+	require.Equal(t, 2, len(backups))
+
+	b, err := ioutil.ReadFile(name)
+	require.Nil(t, err)
+	require.Equal(t, "123456789\n", string(b))
+
+	// Write to a closed writer.
+	_, err = w.Write([]byte("x"))
+	require.Equal(t, errFileWriterClosed, err)
+
+	// Reopen the closed writer.
+	require.Nil(t, w.Reopen())
+	_, err = w.Write([]byte("x"))
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+}
+
+func TestFileWriter_RotateByInterval(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "interval.log")
+
+	now := time.Date(2020, 11, 4, 18, 0, 0, 0, time.UTC)
+	w := &FileWriter{
+		name: name,
+		cfg:  FileConfig{Interval: time.Hour, Compress: true},
+		now:  func() time.Time { return now },
+	}
+	require.Nil(t, w.open())
+	require.Equal(t, now.Add(time.Hour), w.nextRotate)
+
+	_, err := w.Write([]byte("first hour\n"))
+	require.Nil(t, err)
+
+	now = now.Add(time.Minute * 30)
+	_, err = w.Write([]byte("first hour\n"))
+	require.Nil(t, err)
+
+	backups, err := w.backups()
+	require.Nil(t, err)
+	require.Equal(t, 0, len(backups))
+
+	now = now.Add(time.Minute * 30)
+	_, err = w.Write([]byte("second hour\n"))
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+
+	backups, err = w.backups()
+	require.Nil(t, err)
+	require.Equal(t, 1, len(backups))
+	require.True(t, strings.HasSuffix(backups[0], ".log"+compressSuffix), backups[0])
+
+	f, err := os.Open(backups[0])
+	require.Nil(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	gr, err := gzip.NewReader(f)
+	require.Nil(t, err)
+	b, err := ioutil.ReadAll(gr)
+	require.Nil(t, err)
+	require.Equal(t, "first hour\nfirst hour\n", string(b))
+
+	b, err = ioutil.ReadFile(name)
+	require.Nil(t, err)
+	require.Equal(t, "second hour\n", string(b))
+}
+
+func TestFileWriter_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "reopen.log")
+
+	w, err := NewFileWriter(name, nil)
+	require.Nil(t, err)
+
+	_, err = w.Write([]byte("before\n"))
+	require.Nil(t, err)
+
+	// Simulate an external rotation tool.
+	require.Nil(t, os.Rename(name, name+".1"))
+	require.Nil(t, w.Reopen())
+
+	_, err = w.Write([]byte("after\n"))
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+
+	b, err := ioutil.ReadFile(name)
+	require.Nil(t, err)
+	require.Equal(t, "after\n", string(b))
+
+	b, err = ioutil.ReadFile(name + ".1")
+	require.Nil(t, err)
+	require.Equal(t, "before\n", string(b))
+}
+
+func TestFileWriter_RotateFailed(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "failed.log")
+
+	var eb bytes.Buffer
+	w, err := NewFileWriter(name, &FileConfig{MaxSize: 10, ErrorOutput: &eb})
+	require.Nil(t, err)
+	now := time.Date(2020, 11, 4, 18, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	// The backup name is occupied by a non-empty directory, so the rename fails.
+	backup := w.backupName(now)
+	require.Nil(t, os.MkdirAll(filepath.Join(backup, "x"), 0755))
+
+	for i := 0; i < 3; i++ {
+		n, err := w.Write([]byte("123456789\n"))
+		require.Nil(t, err)
+		require.Equal(t, 10, n)
+	}
+	require.Contains(t, eb.String(), "file writer "+name+" error")
+
+	// The original file is reopened and keeps all data.
+	b, err := ioutil.ReadFile(name)
+	require.Nil(t, err)
+	require.Equal(t, strings.Repeat("123456789\n", 3), string(b))
+
+	// The rotation is retried on the next write.
+	require.Nil(t, os.RemoveAll(backup))
+	_, err = w.Write([]byte("123456789\n"))
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+
+	b, err = ioutil.ReadFile(backup)
+	require.Nil(t, err)
+	require.Equal(t, strings.Repeat("123456789\n", 3), string(b))
+	b, err = ioutil.ReadFile(name)
+	require.Nil(t, err)
+	require.Equal(t, "123456789\n", string(b))
+}
+
+func TestFileWriter_CompressFailed(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "compress.log")
+
+	var eb bytes.Buffer
+	w, err := NewFileWriter(name, &FileConfig{MaxSize: 10, Compress: true, ErrorOutput: &eb})
+	require.Nil(t, err)
+	now := time.Date(2020, 11, 4, 18, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	// The name of compressed file is occupied by a directory.
+	backup := w.backupName(now)
+	require.Nil(t, os.Mkdir(backup+compressSuffix, 0755))
+
+	_, err = w.Write([]byte("123456789\n"))
+	require.Nil(t, err)
+	// The data is written even if the compression fails.
+	n, err := w.Write([]byte("second\n"))
+	require.Nil(t, err)
+	require.Equal(t, 7, n)
+	require.Nil(t, w.Close())
+	require.Contains(t, eb.String(), "file writer "+name+" error")
+
+	b, err := ioutil.ReadFile(name)
+	require.Nil(t, err)
+	require.Equal(t, "second\n", string(b))
+	b, err = ioutil.ReadFile(backup)
+	require.Nil(t, err)
+	require.Equal(t, "123456789\n", string(b))
+}
@@ -64,7 +64,7 @@ func TestMultipleExporter(t *testing.T) {
 	e1 := FilterExporter(&b1, MatchGTELevel(DebugLevel))
 	e2 := FilterExporter(&b2, MatchGTELevel(ErrorLevel))
 
-	l := NewDefault().WithExporter(MultipleExporter(e1, e2))
+	l := NewDefault().WithExporter(MultipleExporter(e1, e2)).WithExitFunc(func(code int) {})
 
 	l.Debug().Msg("DebugMessage").Fire()
 	l.Info().Msg("InfoMessage").Fire()
@@ -1,6 +1,9 @@
 package glog
 
 // Filter used to control the export behavior in Exporter.
+//
+// The helpers compare levels in the order of
+// NoLevel < TraceLevel < DebugLevel < InfoLevel < ... < PanicLevel.
 type Filter interface {
 	Match(level Level) bool
 }
@@ -15,28 +18,28 @@ func (f MatchFunc) Match(level Level) bool {
 // MatchGTLevel used to match an level is granter than the level(`lvl`).
 func MatchGTLevel(lvl Level) Filter {
 	return MatchFunc(func(level Level) bool {
-		return level > lvl
+		return level.rank() > lvl.rank()
 	})
 }
 
 // MatchGTELevel used to match an level is granter than or equal the specified level(`lvl`).
 func MatchGTELevel(lvl Level) Filter {
 	return MatchFunc(func(level Level) bool {
-		return level >= lvl
+		return level.rank() >= lvl.rank()
 	})
 }
 
 // MatchLTLevel used to match an level is less than the level(`lvl`).
 func MatchLTLevel(lvl Level) Filter {
 	return MatchFunc(func(level Level) bool {
-		return level < lvl
+		return level.rank() < lvl.rank()
 	})
 }
 
 // MatchLTELevel used to match an level is less than or equal the level(`lvl`).
 func MatchLTELevel(lvl Level) Filter {
 	return MatchFunc(func(level Level) bool {
-		return level <= lvl
+		return level.rank() <= lvl.rank()
 	})
 }
 
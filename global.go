@@ -0,0 +1,76 @@
+package glog
+
+import (
+	"sync/atomic"
+)
+
+// global holds the package-level *Logger.
+var global atomic.Value
+
+func init() {
+	global.Store(NewDefault())
+}
+
+// Global returns the package-level Logger, it's created by NewDefault at startup.
+//
+// The Logger is shared, so the changes made on it affect all its users.
+func Global() *Logger {
+	return global.Load().(*Logger)
+}
+
+// ReplaceGlobal replaces the package-level Logger with l, and returns a
+// function to restore the previous one; It panics if l is nil.
+//
+// The previous Logger is not closed.
+func ReplaceGlobal(l *Logger) func() {
+	if l == nil {
+		panic("glog: ReplaceGlobal with nil logger")
+	}
+	prev := Global()
+	global.Store(l)
+	return func() { ReplaceGlobal(prev) }
+}
+
+// Trace returns an Entry with TraceLevel by the package-level Logger.
+func Trace() *Entry {
+	return Global().newEntry(TraceLevel)
+}
+
+// Debug returns an Entry with DebugLevel by the package-level Logger.
+func Debug() *Entry {
+	return Global().newEntry(DebugLevel)
+}
+
+// Info returns an Entry with InfoLevel by the package-level Logger.
+func Info() *Entry {
+	return Global().newEntry(InfoLevel)
+}
+
+// Warn returns an Entry with WarnLevel by the package-level Logger.
+func Warn() *Entry {
+	return Global().newEntry(WarnLevel)
+}
+
+// Error returns an Entry with ErrorLevel by the package-level Logger.
+func Error() *Entry {
+	return Global().newEntry(ErrorLevel)
+}
+
+// Fatal returns an Entry with FatalLevel by the package-level Logger.
+//
+// See Logger.Fatal for the behavior after the entry be exported.
+func Fatal() *Entry {
+	return Global().newEntry(FatalLevel)
+}
+
+// Panic returns an Entry with PanicLevel by the package-level Logger.
+//
+// See Logger.Panic for the behavior after the entry be exported.
+func Panic() *Entry {
+	return Global().newEntry(PanicLevel)
+}
+
+// V returns an Entry with level by the package-level Logger, see Logger.V.
+func V(site *VSite, level Level) *Entry {
+	return Global().newVEntry(site, level)
+}
@@ -0,0 +1,80 @@
+package glog
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceGlobal(t *testing.T) {
+	prev := Global()
+	require.NotNil(t, prev)
+
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithLevel(InfoLevel).WithCaller(true)
+	restore := ReplaceGlobal(l)
+	require.True(t, Global() == l)
+	require.True(t, FromContextDefault(context.Background()) == l)
+
+	Trace().Msg("trace").Fire()
+	Debug().Msg("debug").Fire()
+	require.Equal(t, "", b.String())
+
+	Info().Msg("info").Fire()
+	Warn().Msg("warn").Fire()
+	Error().Msg("error").Fire()
+	require.Contains(t, b.String(), "[info] info")
+	require.Contains(t, b.String(), "[warn] warn")
+	require.Contains(t, b.String(), "[error] error")
+	// The caller is the call site of package-level function.
+	require.Contains(t, b.String(), "global_test.go:")
+
+	// The vmodule matches the call site of package-level function.
+	vm, err := NewVModule("global_test=1")
+	require.Nil(t, err)
+	l.WithVModule(vm)
+	b.Reset()
+	var site VSite
+	V(&site, DebugLevel).Msg("debug").Fire()
+	require.Contains(t, b.String(), "[debug] debug")
+
+	var exited int
+	var panicked interface{}
+	l.WithExitFunc(func(code int) { exited = code })
+	l.WithPanicFunc(func(v interface{}) { panicked = v })
+	Fatal().Msg("fatal").Fire()
+	Panic().Msg("panic").Fire()
+	require.Equal(t, 1, exited)
+	require.Equal(t, "panic", panicked)
+
+	restore()
+	require.True(t, Global() == prev)
+	require.True(t, FromContextDefault(context.Background()) == prev)
+
+	require.Panics(t, func() { ReplaceGlobal(nil) })
+}
+
+func TestReplaceGlobal_Concurrency(t *testing.T) {
+	var exported uint64
+	l := NewDefault().WithExporter(&countExporter{n: &exported})
+	defer ReplaceGlobal(l)()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Info().Msg("info").Fire()
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		ReplaceGlobal(l)
+	}
+	wg.Wait()
+	require.Equal(t, uint64(400), exported)
+}
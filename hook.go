@@ -0,0 +1,41 @@
+package glog
+
+import (
+	"context"
+)
+
+var _ Hook = (HookFunc)(nil)
+
+// Hook is invoked on every entry before it is exported.
+//
+// The hooks are called in the order they were registered, after the
+// sampler and before the fixed fields and caller be added.
+type Hook interface {
+	// Run is called with the level, the ctx of Logger and the entry;
+	// It can add fields into e, and returns false to drop the entry,
+	// the remaining hooks are not called then.
+	//
+	// Only the export is dropped, the entry with FatalLevel or PanicLevel
+	// still calls the exitFunc or panicFunc of Logger.
+	//
+	// NOTICE: Run is called concurrently, and it must not call Fire on e.
+	Run(ctx context.Context, level Level, e *Entry) bool
+}
+
+// HookFunc is an adapter to allow the use of ordinary functions as Hook.
+type HookFunc func(ctx context.Context, level Level, e *Entry) bool
+
+// Run implements Hook.
+func (f HookFunc) Run(ctx context.Context, level Level, e *Entry) bool {
+	return f(ctx, level, e)
+}
+
+// runHooks returns false if any hook vetoes the entry.
+func (e *Entry) runHooks() bool {
+	for _, h := range e.core.hooks {
+		if !h.Run(e.core.ctx, e.record.level, e) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package glog
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_WithHooks(t *testing.T) {
+	type traceKey struct{}
+	ctx := context.WithValue(context.Background(), traceKey{}, "t-001")
+
+	var b bytes.Buffer
+	var order []string
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithContext(ctx).WithCaller(true)
+	l.WithFields().AddString("fixed", "v")
+
+	l.WithHooks(
+		HookFunc(func(ctx context.Context, level Level, e *Entry) bool {
+			order = append(order, "trace")
+			e.String("trace_id", ctx.Value(traceKey{}).(string))
+			return true
+		}),
+		HookFunc(func(ctx context.Context, level Level, e *Entry) bool {
+			order = append(order, "level")
+			e.String("lvl", level.String())
+			return true
+		}),
+	)
+
+	l.Info().Msg("hello").Fire()
+	require.Equal(t, []string{"trace", "level"}, order)
+	// The fields of hooks are added before the fixed fields and caller.
+	require.Contains(t, b.String(), "[info] hello trace_id=t-001 lvl=info fixed=v (/")
+	require.Contains(t, b.String(), "hook_test.go:")
+
+	// The hooks are inherited by Clone.
+	b.Reset()
+	order = nil
+	l.Clone().Warn().Msg("clone").Fire()
+	require.Equal(t, []string{"trace", "level"}, order)
+	require.Contains(t, b.String(), "lvl=warn")
+
+	// The disabled entry does not run hooks.
+	b.Reset()
+	order = nil
+	l.WithLevel(InfoLevel)
+	l.Debug().Msg("debug").Fire()
+	require.Nil(t, order)
+	require.Equal(t, "", b.String())
+
+	l.ResetHooks()
+	l.Info().Msg("reset").Fire()
+	require.Nil(t, order)
+	require.NotContains(t, b.String(), "trace_id")
+}
+
+func TestLogger_WithHooks_Veto(t *testing.T) {
+	export := &CustomExporter{}
+	var called int
+	var exited, panicked bool
+	l := NewDefault().WithExporter(export).
+		WithExitFunc(func(int) { exited = true }).
+		WithPanicFunc(func(interface{}) { panicked = true })
+	l.WithHooks(
+		HookFunc(func(ctx context.Context, level Level, e *Entry) bool {
+			return level != DebugLevel && level < FatalLevel
+		}),
+		HookFunc(func(ctx context.Context, level Level, e *Entry) bool {
+			called++
+			return true
+		}),
+	)
+
+	l.Debug().Msg("dropped").Fire()
+	require.Nil(t, export.record)
+	require.Equal(t, 0, called)
+
+	// The export is dropped, but the terminal actions are still performed.
+	l.Fatal().Msg("dropped").Fire()
+	require.Nil(t, export.record)
+	require.True(t, exited)
+	l.Panic().Msg("dropped").Fire()
+	require.Nil(t, export.record)
+	require.True(t, panicked)
+	require.Equal(t, 0, called)
+
+	l.Info().Msg("exported").Fire()
+	require.NotNil(t, export.record)
+	require.Equal(t, "exported", export.record.Message())
+	require.Equal(t, 1, called)
+}
+
+func TestLogger_WithHooks_Concurrency(t *testing.T) {
+	var infoCount, errorCount uint64
+	countHook := HookFunc(func(ctx context.Context, level Level, e *Entry) bool {
+		switch level {
+		case InfoLevel:
+			atomic.AddUint64(&infoCount, 1)
+		case ErrorLevel:
+			atomic.AddUint64(&errorCount, 1)
+		}
+		return true
+	})
+
+	var exported uint64
+	l := NewDefault().WithExporter(&countExporter{n: &exported}).WithLevel(TraceLevel)
+	l.WithHooks(countHook)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if i%2 == 0 {
+					l.Info().Int("j", j).Fire()
+				} else {
+					l.Error().Int("j", j).Fire()
+				}
+			}
+		}(i)
+	}
+	// Register hooks while logging.
+	for i := 0; i < 10; i++ {
+		l.WithHooks(HookFunc(func(ctx context.Context, level Level, e *Entry) bool { return true }))
+	}
+	wg.Wait()
+
+	require.Equal(t, uint64(400), atomic.LoadUint64(&infoCount))
+	require.Equal(t, uint64(400), atomic.LoadUint64(&errorCount))
+	require.Equal(t, uint64(800), atomic.LoadUint64(&exported))
+}
+
+type countExporter struct {
+	n *uint64
+}
+
+func (exp *countExporter) Export(record *Record) error {
+	atomic.AddUint64(exp.n, 1)
+	return nil
+}
+
+func (exp *countExporter) Close() error { return nil }
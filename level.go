@@ -1,10 +1,27 @@
 package glog
 
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+var _ flag.Getter = (*Level)(nil)
+
 // Level declares log level.
 type Level int8
 
 const (
-	// NoLevel is the lowest level.
+	// TraceLevel defines trace log level, it's noisier than DebugLevel.
+	//
+	// Notes: TraceLevel is -1 so that the values of other levels are kept
+	// unchanged; it's lower than NoLevel in value, but the logger with
+	// NoLevel logs all levels including TraceLevel, see rank.
+	TraceLevel Level = -1
+)
+
+const (
+	// NoLevel is the zero value of Level, it's regarded as the lowest level when comparing.
 	NoLevel Level = iota
 	// DebugLevel defines debug log level.
 	DebugLevel
@@ -16,10 +33,23 @@ const (
 	ErrorLevel
 	// FatalLevel defines fatal log level.
 	FatalLevel
+	// PanicLevel defines panic log level.
+	PanicLevel
 )
 
+// rank returns the order of l used to compare the levels; NoLevel is the
+// lowest, so that NoLevel as the minimum level enables TraceLevel too.
+func (l Level) rank() int {
+	if l == NoLevel {
+		return int(TraceLevel) - 1
+	}
+	return int(l)
+}
+
 func (l Level) String() string {
 	switch l {
+	case TraceLevel:
+		return "trace"
 	case DebugLevel:
 		return "debug"
 	case InfoLevel:
@@ -30,7 +60,88 @@ func (l Level) String() string {
 		return "error"
 	case FatalLevel:
 		return "fatal"
+	case PanicLevel:
+		return "panic"
+	default:
+		return ""
+	}
+}
+
+// CapitalString returns the upper-case name of the level, e.g. "INFO".
+func (l Level) CapitalString() string {
+	switch l {
+	case TraceLevel:
+		return "TRACE"
+	case DebugLevel:
+		return "DEBUG"
+	case InfoLevel:
+		return "INFO"
+	case WarnLevel:
+		return "WARN"
+	case ErrorLevel:
+		return "ERROR"
+	case FatalLevel:
+		return "FATAL"
+	case PanicLevel:
+		return "PANIC"
 	default:
 		return ""
 	}
 }
+
+// ParseLevel returns the Level represented by the text, it's the inverse of Level.String.
+//
+// The text is case-insensitive, and the following aliases are accepted:
+// "warning" for WarnLevel, "err" for ErrorLevel, "crit" and "critical" for FatalLevel.
+// An empty text represents NoLevel.
+func ParseLevel(text string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "":
+		return NoLevel, nil
+	case "trace":
+		return TraceLevel, nil
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error", "err":
+		return ErrorLevel, nil
+	case "fatal", "crit", "critical":
+		return FatalLevel, nil
+	case "panic":
+		return PanicLevel, nil
+	default:
+		return NoLevel, fmt.Errorf("glog: unrecognized level %q", text)
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l Level) MarshalText() ([]byte, error) {
+	s := l.String()
+	if s == "" && l != NoLevel {
+		return nil, fmt.Errorf("glog: unknown level %d", l)
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// Set implements flag.Value; so a *Level can be used as command-line flag.
+func (l *Level) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
+
+// Get implements flag.Getter.
+func (l *Level) Get() interface{} {
+	return *l
+}
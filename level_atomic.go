@@ -0,0 +1,110 @@
+package glog
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"sync/atomic"
+)
+
+var (
+	_ http.Handler = (*AtomicLevel)(nil)
+	_ flag.Getter  = (*AtomicLevel)(nil)
+)
+
+// AtomicLevel is an atomically changeable Level. It can be shared by
+// multiple loggers, changing it takes effect on all of them immediately.
+type AtomicLevel struct {
+	l int32
+}
+
+// NewAtomicLevel returns an AtomicLevel with the initial level.
+func NewAtomicLevel(level Level) *AtomicLevel {
+	return &AtomicLevel{l: int32(level)}
+}
+
+// Level returns the current level.
+func (al *AtomicLevel) Level() Level {
+	return Level(atomic.LoadInt32(&al.l))
+}
+
+// SetLevel changes the level atomically.
+func (al *AtomicLevel) SetLevel(level Level) {
+	atomic.StoreInt32(&al.l, int32(level))
+}
+
+// Enabled returns true if the given level is at or above the current level.
+func (al *AtomicLevel) Enabled(level Level) bool {
+	return level.rank() >= al.Level().rank()
+}
+
+func (al *AtomicLevel) String() string {
+	return al.Level().String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (al *AtomicLevel) MarshalText() ([]byte, error) {
+	return al.Level().MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (al *AtomicLevel) UnmarshalText(text []byte) error {
+	var level Level
+	if err := level.UnmarshalText(text); err != nil {
+		return err
+	}
+	al.SetLevel(level)
+	return nil
+}
+
+// Set implements flag.Value; so an *AtomicLevel can be used as command-line flag.
+func (al *AtomicLevel) Set(s string) error {
+	return al.UnmarshalText([]byte(s))
+}
+
+// Get implements flag.Getter.
+func (al *AtomicLevel) Get() interface{} {
+	return al.Level()
+}
+
+type levelPayload struct {
+	Level *Level `json:"level"`
+}
+
+type levelError struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP implements http.Handler; It used to get or change the level at runtime.
+//
+// GET returns the current level, e.g. {"level":"info"}.
+// PUT changes the level by the request body in same format and returns the new level.
+func (al *AtomicLevel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req levelPayload
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = enc.Encode(levelError{Error: "invalid request body: " + err.Error()})
+			return
+		}
+		if req.Level == nil || *req.Level == NoLevel {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = enc.Encode(levelError{Error: "must specify the level"})
+			return
+		}
+		al.SetLevel(*req.Level)
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPut)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_ = enc.Encode(levelError{Error: "only GET and PUT are supported"})
+		return
+	}
+
+	level := al.Level()
+	_ = enc.Encode(levelPayload{Level: &level})
+}
@@ -0,0 +1,131 @@
+package glog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAtomicLevel(t *testing.T) {
+	al := NewAtomicLevel(InfoLevel)
+	require.Equal(t, InfoLevel, al.Level())
+	require.False(t, al.Enabled(DebugLevel))
+	require.True(t, al.Enabled(InfoLevel))
+	require.True(t, al.Enabled(ErrorLevel))
+
+	al.SetLevel(DebugLevel)
+	require.Equal(t, DebugLevel, al.Level())
+	require.Equal(t, "debug", al.String())
+
+	require.Nil(t, al.UnmarshalText([]byte("warn")))
+	require.Equal(t, WarnLevel, al.Level())
+	b, err := al.MarshalText()
+	require.Nil(t, err)
+	require.Equal(t, "warn", string(b))
+	require.NotNil(t, al.UnmarshalText([]byte("unknown")))
+	require.Equal(t, WarnLevel, al.Level())
+}
+
+func TestAtomicLevel_ServeHTTP(t *testing.T) {
+	al := NewAtomicLevel(InfoLevel)
+	srv := httptest.NewServer(al)
+	defer srv.Close()
+
+	do := func(method string, body string) (int, string) {
+		req, err := http.NewRequest(method, srv.URL, strings.NewReader(body))
+		require.Nil(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		var b bytes.Buffer
+		_, err = b.ReadFrom(resp.Body)
+		require.Nil(t, err)
+		return resp.StatusCode, strings.TrimSpace(b.String())
+	}
+
+	code, body := do(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, `{"level":"info"}`, body)
+
+	code, body = do(http.MethodPut, `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, `{"level":"debug"}`, body)
+	require.Equal(t, DebugLevel, al.Level())
+
+	code, body = do(http.MethodPut, `{"level":"unknown"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Contains(t, body, "unrecognized level")
+	require.Equal(t, DebugLevel, al.Level())
+
+	code, _ = do(http.MethodPut, `{}`)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	code, body = do(http.MethodPut, `{"level":""}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Contains(t, body, "must specify the level")
+	require.Equal(t, DebugLevel, al.Level())
+
+	code, _ = do(http.MethodPost, `{"level":"info"}`)
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+	require.Equal(t, DebugLevel, al.Level())
+}
+
+func TestLogger_AtomicLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithLevel(InfoLevel)
+	nl := l.Clone()
+	require.True(t, l.AtomicLevel() == nl.AtomicLevel())
+
+	l.Debug().Msg("DebugMessage1").Fire()
+	nl.Debug().Msg("DebugMessage1").Fire()
+	require.NotContains(t, b.String(), "DebugMessage1")
+
+	// The level change takes effect on the logger shares the AtomicLevel.
+	l.AtomicLevel().SetLevel(DebugLevel)
+	nl.Debug().Msg("DebugMessage2").Fire()
+	require.Contains(t, b.String(), "DebugMessage2")
+
+	// Set a separate level for the cloned logger.
+	nl.WithAtomicLevel(NewAtomicLevel(ErrorLevel))
+	require.Equal(t, DebugLevel, l.Level())
+	require.Equal(t, ErrorLevel, nl.Level())
+}
+
+func TestLogger_Clone_Level(t *testing.T) {
+	l := NewDefault().WithLevel(InfoLevel)
+	nl := l.Clone()
+	require.True(t, l.AtomicLevel() == nl.AtomicLevel())
+
+	// The cloned logger shares the level.
+	nl.WithLevel(ErrorLevel)
+	require.Equal(t, ErrorLevel, l.Level())
+	l.WithLevel(InfoLevel)
+	require.Equal(t, InfoLevel, nl.Level())
+
+	// A separate level can be set by WithAtomicLevel.
+	nl.WithAtomicLevel(NewAtomicLevel(l.Level()))
+	require.False(t, l.AtomicLevel() == nl.AtomicLevel())
+	nl.WithLevel(DebugLevel)
+	require.Equal(t, InfoLevel, l.Level())
+	require.Equal(t, DebugLevel, nl.Level())
+	l.WithLevel(ErrorLevel)
+	require.Equal(t, DebugLevel, nl.Level())
+
+	// The derived loggers share the level.
+	child := l.With().Logger()
+	named := l.Named("db")
+	l.WithLevel(WarnLevel)
+	require.Equal(t, WarnLevel, child.Level())
+	require.Equal(t, WarnLevel, named.Level())
+
+	// The level is still available after Close.
+	require.Nil(t, l.Close())
+	require.Equal(t, WarnLevel, l.Level())
+	require.Nil(t, l.Debug())
+}
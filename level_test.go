@@ -0,0 +1,182 @@
+package glog
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevel_MarshalText(t *testing.T) {
+	levels := []Level{TraceLevel, NoLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel}
+	for _, level := range levels {
+		b, err := level.MarshalText()
+		require.Nil(t, err)
+		require.Equal(t, level.String(), string(b))
+		require.Equal(t, strings.ToUpper(level.String()), level.CapitalString())
+
+		var nl Level
+		require.Nil(t, nl.UnmarshalText(b))
+		require.Equal(t, level, nl)
+	}
+
+	_, err := Level(100).MarshalText()
+	require.NotNil(t, err)
+
+	var l Level
+	require.NotNil(t, l.UnmarshalText([]byte("unknown")))
+}
+
+func TestLevel_JSON(t *testing.T) {
+	type config struct {
+		Level Level `json:"level"`
+	}
+
+	b, err := json.Marshal(&config{Level: WarnLevel})
+	require.Nil(t, err)
+	require.Equal(t, `{"level":"warn"}`, string(b))
+
+	var c config
+	require.Nil(t, json.Unmarshal([]byte(`{"level":"error"}`), &c))
+	require.Equal(t, ErrorLevel, c.Level)
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]Level{
+		"":         NoLevel,
+		"trace":    TraceLevel,
+		"TRACE":    TraceLevel,
+		"debug":    DebugLevel,
+		"DEBUG":    DebugLevel,
+		"info":     InfoLevel,
+		" Info ":   InfoLevel,
+		"warn":     WarnLevel,
+		"warning":  WarnLevel,
+		"WARNING":  WarnLevel,
+		"error":    ErrorLevel,
+		"err":      ErrorLevel,
+		"fatal":    FatalLevel,
+		"crit":     FatalLevel,
+		"Critical": FatalLevel,
+		"panic":    PanicLevel,
+	}
+	for text, expected := range cases {
+		level, err := ParseLevel(text)
+		require.Nil(t, err, text)
+		require.Equal(t, expected, level, text)
+	}
+
+	_, err := ParseLevel("verbose")
+	require.NotNil(t, err)
+}
+
+func TestLevel_Flag(t *testing.T) {
+	level := InfoLevel
+	al := NewAtomicLevel(InfoLevel)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&level, "level", "log level")
+	fs.Var(al, "atomic-level", "log level")
+
+	require.Nil(t, fs.Parse([]string{"-level", "Warning", "-atomic-level=err"}))
+	require.Equal(t, WarnLevel, level)
+	require.Equal(t, ErrorLevel, al.Level())
+	require.Equal(t, WarnLevel, fs.Lookup("level").Value.(flag.Getter).Get())
+	require.Equal(t, ErrorLevel, fs.Lookup("atomic-level").Value.(flag.Getter).Get())
+
+	require.NotNil(t, fs.Parse([]string{"-level", "unknown"}))
+	require.Equal(t, WarnLevel, level)
+}
+
+func TestLevel_RoundTripWithEncoder(t *testing.T) {
+	levels := []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel, PanicLevel}
+
+	t.Run("JSON", func(t *testing.T) {
+		var b bytes.Buffer
+		l := NewDefault().WithEncoderFunc(JSONEncoder).WithExporter(StandardExporter(&b)).WithLevel(TraceLevel).
+			WithExitFunc(func(code int) {}).WithPanicFunc(func(v interface{}) {})
+
+		for _, level := range levels {
+			b.Reset()
+			l.newEntry(level).Any("field", level).Fire()
+
+			var m struct {
+				Level Level `json:"level"`
+				Field Level `json:"field"`
+			}
+			require.Nil(t, json.Unmarshal(b.Bytes(), &m), b.String())
+			require.Equal(t, level, m.Level)
+			require.Equal(t, level, m.Field)
+		}
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		var b bytes.Buffer
+		l := NewDefault().WithExporter(StandardExporter(&b)).WithLevel(TraceLevel).
+			WithExitFunc(func(code int) {}).WithPanicFunc(func(v interface{}) {})
+
+		for _, level := range levels {
+			b.Reset()
+			l.newEntry(level).Any("field", level).Fire()
+
+			s := strings.TrimSpace(b.String())
+			i, j := strings.Index(s, "["), strings.Index(s, "]")
+			require.True(t, i >= 0 && j > i, s)
+
+			var nl Level
+			require.Nil(t, nl.UnmarshalText([]byte(s[i+1:j])))
+			require.Equal(t, level, nl)
+
+			require.True(t, strings.HasSuffix(s, " field="+level.String()), s)
+			require.Nil(t, nl.Set(strings.TrimPrefix(s[strings.LastIndex(s, " ")+1:], "field=")))
+			require.Equal(t, level, nl)
+		}
+	})
+}
+
+func TestLevel_Order(t *testing.T) {
+	// The values of the levels must not be changed, they may be persisted.
+	require.Equal(t, Level(-1), TraceLevel)
+	require.Equal(t, Level(0), NoLevel)
+	require.Equal(t, Level(1), DebugLevel)
+	require.Equal(t, Level(2), InfoLevel)
+	require.Equal(t, Level(3), WarnLevel)
+	require.Equal(t, Level(4), ErrorLevel)
+	require.Equal(t, Level(5), FatalLevel)
+	require.Equal(t, Level(6), PanicLevel)
+
+	require.True(t, MatchLTLevel(DebugLevel).Match(TraceLevel))
+	require.True(t, MatchLTELevel(DebugLevel).Match(TraceLevel))
+	require.True(t, MatchGTELevel(TraceLevel).Match(DebugLevel))
+	require.True(t, MatchEQLevel(TraceLevel).Match(TraceLevel))
+	require.False(t, MatchGTLevel(TraceLevel).Match(TraceLevel))
+	require.False(t, MatchGTELevel(DebugLevel).Match(TraceLevel))
+
+	// NoLevel is the lowest when comparing.
+	require.True(t, MatchGTELevel(NoLevel).Match(TraceLevel))
+	require.True(t, MatchGTLevel(NoLevel).Match(TraceLevel))
+	require.False(t, MatchLTLevel(NoLevel).Match(TraceLevel))
+	require.True(t, MatchLTLevel(TraceLevel).Match(NoLevel))
+	require.True(t, NewAtomicLevel(NoLevel).Enabled(TraceLevel))
+}
+
+func TestLogger_NoLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithLevel(NoLevel)
+
+	l.Trace().Msg("trace").Fire()
+	l.Debug().Msg("debug").Fire()
+	require.Contains(t, b.String(), "[trace] trace")
+	require.Contains(t, b.String(), "[debug] debug")
+
+	// The stack level NoLevel adds stack trace for all levels.
+	b.Reset()
+	l.WithStack(true).WithStackLevel(NoLevel)
+	l.Trace().Msg("trace").Fire()
+	require.Contains(t, b.String(), "TestLogger_NoLevel")
+}
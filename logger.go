@@ -5,16 +5,34 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"time"
+	"sync"
+	"sync/atomic"
 )
 
 // Logger declares the logger.
+//
+// A Logger is safe for concurrent use; it can be reconfigured at runtime
+// while other goroutines are logging.
 type Logger struct {
+	// mu serializes the updates of core.
+	mu sync.Mutex
+
+	// core holds a *loggerCore. The *loggerCore never be modified after stored,
+	// any changes make a copy of it and store the new one.
+	core atomic.Value
+
+	// isRoot indicates the logger whether is a root logger.
+	isRoot bool
+}
+
+// loggerCore is an immutable snapshot of the logger's configuration.
+type loggerCore struct {
 	ctx context.Context
 
 	// level set the minimum accepted level,
 	// less than the level will be ignored;
-	level Level
+	// It's shared by the cloned loggers.
+	level *AtomicLevel
 
 	// timeLayout set the time format in log message.
 	timeLayout string
@@ -22,122 +40,301 @@ type Logger struct {
 	// caller set whether adds caller info in log message.
 	caller bool
 
+	// callerSkip is the number of additional stack frames to skip
+	// when getting the caller and stack trace.
+	callerSkip int
+
+	// callerFunc set whether adds the function name in caller info.
+	callerFunc bool
+
+	// callerFormat set the format of caller info, CallerFormatFull or CallerFormatShort.
+	callerFormat int8
+
+	// stack set whether adds stack trace in log message automatically
+	// for the entries at or above stackLevel.
+	stack      bool
+	stackLevel Level
+
 	// encoderFunc used to get a new encoder in log entry.
 	// Notes: change the encoderFunc will cause the fields empty and rebuild.
 	encoderFunc EncoderFunc
 
-	// fields add fixed field into every log entry
-	fields Encoder
+	// fields is the encoded fixed fields that add into every log entry.
+	fields []byte
+
+	// fixedFields is the captured fixed fields for Record and the EncoderExporters,
+	// it's in the same order as fields.
+	fixedFields []Field
+
+	// recordFields set whether captures the fields of entry for Record.
+	recordFields bool
 
 	// exporter used to export the log by every entry.Fire
 	exporter Exporter
 
+	// encoders is the distinct EncoderExporters in exporter.
+	encoders []*encoderExporter
+
 	// errorOutput is the error output writer of this logger
 	// logger will write error message into this while failed to log message
 	//
 	// NOTE: logger will not check returning error of this writer
 	errorOutput io.Writer
 
-	// isRoot indicates the logger whether is a root logger.
-	isRoot bool
+	// exitFunc is called after a FatalLevel entry be exported.
+	exitFunc func(code int)
+
+	// panicFunc is called with the message after a PanicLevel entry be exported.
+	panicFunc func(v interface{})
+
+	// sampler used to drop the entries when fire, nil means export all entries.
+	sampler *Sampler
+
+	// hooks are invoked on every entry before export.
+	hooks []Hook
+
+	// name is the dotted name of logger, empty if the logger is not named.
+	name string
+	// namedLevel caches the level of name in registry.
+	namedLevel *namedLevel
+	// registry holds the levels set by name prefix;
+	// It's shared by the cloned loggers.
+	registry *levelRegistry
+
+	// vmodule enables the entries below level by the call site of V, nil means disabled.
+	vmodule *VModule
 }
 
+func defaultPanicFunc(v interface{}) { panic(v) }
+
 func NewDefault() *Logger {
 	l := &Logger{
-		ctx:         context.Background(),
-		level:       DebugLevel,
-		timeLayout:  defaultTimeLayout,
-		caller:      false,
-		encoderFunc: TextEncoder,
-		fields:      nil,
-		exporter:    DefaultExporter,
-		errorOutput: os.Stderr,
-		isRoot:      true,
+		isRoot: true,
 	}
-	l.fields = l.encoderFunc()
+	l.core.Store(&loggerCore{
+		ctx:          context.Background(),
+		level:        NewAtomicLevel(DebugLevel),
+		timeLayout:   defaultTimeLayout,
+		caller:       false,
+		callerSkip:   0,
+		callerFunc:   false,
+		callerFormat: CallerFormatFull,
+		stack:        false,
+		stackLevel:   ErrorLevel,
+		encoderFunc:  TextEncoder,
+		fields:       nil,
+		exporter:     DefaultExporter,
+		errorOutput:  os.Stderr,
+		exitFunc:     os.Exit,
+		panicFunc:    defaultPanicFunc,
+		registry:     newLevelRegistry(),
+	})
+	return l
+}
+
+// load returns the current configuration snapshot.
+func (l *Logger) load() *loggerCore {
+	return l.core.Load().(*loggerCore)
+}
+
+// update applies fn to a copy of the current configuration and stores the copy.
+func (l *Logger) update(fn func(c *loggerCore)) *Logger {
+	l.mu.Lock()
+	c := *l.load()
+	fn(&c)
+	l.core.Store(&c)
+	l.mu.Unlock()
 	return l
 }
 
 // Context returns the ctx where in the logger.
 func (l *Logger) Context() context.Context {
-	return l.ctx
+	return l.load().ctx
+}
+
+// Level returns the logger's level.
+//
+// Notes: the level set by WithNamedLevel is not included.
+func (l *Logger) Level() Level {
+	return l.load().level.Level()
+}
+
+// AtomicLevel returns the AtomicLevel referenced by the logger.
+func (l *Logger) AtomicLevel() *AtomicLevel {
+	return l.load().level
 }
 
 // WithContext will reset logger's ctx.
 func (l *Logger) WithContext(ctx context.Context) *Logger {
-	l.ctx = ctx
-	return l
+	return l.update(func(c *loggerCore) { c.ctx = ctx })
 }
 
 // WithLevel will reset logger's level.
+//
+// Notes: the level is shared by the loggers derived by Clone, With and Named;
+// use WithAtomicLevel(NewAtomicLevel(level)) to set a separate level.
 func (l *Logger) WithLevel(level Level) *Logger {
-	l.level = level
+	l.load().level.SetLevel(level)
 	return l
 }
 
+// WithAtomicLevel will reset the AtomicLevel referenced by the logger,
+// e.g. WithAtomicLevel(parent.AtomicLevel()) to share the level with parent.
+func (l *Logger) WithAtomicLevel(al *AtomicLevel) *Logger {
+	return l.update(func(c *loggerCore) { c.level = al })
+}
+
 // WithTimeLayout will reset logger's timeLayout.
 func (l *Logger) WithTimeLayout(layout string) *Logger {
-	l.timeLayout = layout
-	return l
+	return l.update(func(c *loggerCore) { c.timeLayout = layout })
 }
 
 // WithCaller will reset logger's caller.
 func (l *Logger) WithCaller(ok bool) *Logger {
-	l.caller = ok
-	return l
+	return l.update(func(c *loggerCore) { c.caller = ok })
+}
+
+// WithCallerSkip will reset logger's callerSkip, it's the number of additional
+// stack frames to skip when getting the caller and stack trace;
+// This used by the wrappers of Logger to report the correct frame.
+func (l *Logger) WithCallerSkip(skip int) *Logger {
+	return l.update(func(c *loggerCore) { c.callerSkip = skip })
+}
+
+// WithCallerFunc will reset whether adds the function name in caller info.
+func (l *Logger) WithCallerFunc(ok bool) *Logger {
+	return l.update(func(c *loggerCore) { c.callerFunc = ok })
+}
+
+// WithCallerFormat will reset logger's callerFormat;
+// it can be CallerFormatFull or CallerFormatShort.
+func (l *Logger) WithCallerFormat(layout int8) *Logger {
+	return l.update(func(c *loggerCore) { c.callerFormat = layout })
+}
+
+// WithStack will reset whether adds stack trace automatically
+// for the entries at or above the stack level.
+func (l *Logger) WithStack(ok bool) *Logger {
+	return l.update(func(c *loggerCore) { c.stack = ok })
+}
+
+// WithStackLevel will reset logger's stack level, default is ErrorLevel.
+// The stack trace is added only if WithStack(true) be set.
+func (l *Logger) WithStackLevel(level Level) *Logger {
+	return l.update(func(c *loggerCore) { c.stackLevel = level })
 }
 
 // WithExporter will reset logger's exporter.
+//
+// If the exporter is an EncodingExporter, e.g. returned by EncoderExporter,
+// each entry is encoded once more by every EncoderExporter it contains.
 func (l *Logger) WithExporter(exporter Exporter) *Logger {
-	l.exporter = exporter
-	return l
+	return l.update(func(c *loggerCore) {
+		c.exporter = exporter
+		c.encoders = encoderExporters(exporter)
+	})
 }
 
 // WithEncoderFunc reset set logger's encoderFunc.
 func (l *Logger) WithEncoderFunc(f EncoderFunc) *Logger {
-	l.encoderFunc = f
-	if l.fields != nil {
-		_ = l.fields.Close()
-	}
-	l.fields = f()
-	return l
+	return l.update(func(c *loggerCore) {
+		c.encoderFunc = f
+		c.fields = nil
+		c.fixedFields = nil
+	})
+}
+
+// WithRecordFields will reset whether captures the top-level fields of entry,
+// they can be got by Record.Fields in the Exporter; It's disabled by default.
+func (l *Logger) WithRecordFields(ok bool) *Logger {
+	return l.update(func(c *loggerCore) { c.recordFields = ok })
 }
 
 // WithErrorOutput reset set logger's exporter.
 func (l *Logger) WithErrorOutput(w io.Writer) *Logger {
-	l.errorOutput = w
-	return l
+	return l.update(func(c *loggerCore) { c.errorOutput = w })
+}
+
+// WithExitFunc will reset logger's exitFunc; it is called after
+// a FatalLevel entry be exported, default is os.Exit.
+func (l *Logger) WithExitFunc(f func(code int)) *Logger {
+	return l.update(func(c *loggerCore) { c.exitFunc = f })
+}
+
+// WithPanicFunc will reset logger's panicFunc; it is called with the message
+// after a PanicLevel entry be exported, default is the builtin panic.
+func (l *Logger) WithPanicFunc(f func(v interface{})) *Logger {
+	return l.update(func(c *loggerCore) { c.panicFunc = f })
+}
+
+// WithSampler will reset logger's sampler, nil means disable sampling.
+func (l *Logger) WithSampler(s *Sampler) *Logger {
+	return l.update(func(c *loggerCore) { c.sampler = s })
+}
+
+// WithHooks appends the hooks that invoked on every entry before export,
+// the hooks are inherited by the cloned loggers.
+func (l *Logger) WithHooks(hooks ...Hook) *Logger {
+	return l.update(func(c *loggerCore) {
+		c.hooks = append(append(make([]Hook, 0, len(c.hooks)+len(hooks)), c.hooks...), hooks...)
+	})
+}
+
+// ResetHooks removes all hooks of logger.
+func (l *Logger) ResetHooks() *Logger {
+	return l.update(func(c *loggerCore) { c.hooks = nil })
 }
 
 // WithFields for add fixed fields into the log entry.
+//
+// Every call of the returned Encoder copies the fields and stores
+// the new one, so it's safe to be called while logging; The call that
+// returns an error adds nothing, e.g. an ObjectMarshaler fails halfway.
+// The fields are added into l, use With to create a child Logger instead.
 func (l *Logger) WithFields() Encoder {
-	return l.fields
+	return &fieldsEncoder{l: l}
 }
 
 // ResetFields for clear the data in fields.
 func (l *Logger) ResetFields() Encoder {
-	_ = l.fields.Close()
-	l.fields = l.encoderFunc()
-	return l.fields
+	l.update(func(c *loggerCore) {
+		c.fields = nil
+		c.fixedFields = nil
+	})
+	return &fieldsEncoder{l: l}
+}
+
+// addFields encodes the fields by fn and appends them to the fixed fields;
+// The fields are discarded if fn returns an error.
+func (l *Logger) addFields(fn func(enc Encoder) error) (err error) {
+	l.update(func(c *loggerCore) {
+		enc := c.encoderFunc()
+		defer func() {
+			_ = enc.Close()
+		}()
+		if err = enc.WriteIn(c.fields); err != nil {
+			return
+		}
+		rec := &recordEncoder{Encoder: enc}
+		if err = fn(rec); err != nil {
+			return
+		}
+		c.fields = append([]byte(nil), enc.Bytes()...)
+		c.fixedFields = append(append([]Field(nil), c.fixedFields...), rec.fields...)
+	})
+	return
 }
 
 // Clone do copy and returns a new logger.
+//
+// Notes: the new logger shares the AtomicLevel and the named levels with l,
+// use WithAtomicLevel(NewAtomicLevel(l.Level())) on it to set a separate level.
 func (l *Logger) Clone() *Logger {
 	nl := &Logger{
-		ctx:         l.ctx,
-		level:       l.level,
-		timeLayout:  l.timeLayout,
-		caller:      l.caller,
-		exporter:    l.exporter,
-		encoderFunc: l.encoderFunc,
-		fields:      l.encoderFunc(),
-		errorOutput: l.errorOutput,
-		isRoot:      false,
-	}
-	err := nl.fields.WriteIn(l.fields.Bytes())
-	if err != nil {
-		_, _ = fmt.Fprintf(l.errorOutput, "[glog]: %s write fields fail when clone: %v\n", time.Now().Format(l.timeLayout), err)
+		isRoot: false,
 	}
+	// The core is immutable, so it can be shared.
+	nl.core.Store(l.load())
 	return nl
 }
 
@@ -150,22 +347,17 @@ func (l *Logger) Close() error {
 	}
 	var errs []error
 
-	if err := l.fields.Close(); err != nil {
-		errs = append(errs, err)
-	}
-	if l.isRoot {
+	l.mu.Lock()
+	c := l.load()
+	if l.isRoot && c.exporter != nil {
 		// Close the exporter is the root logger.
-		if err := l.exporter.Close(); err != nil {
+		if err := c.exporter.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
-
-	l.ctx = nil
-	l.timeLayout = ""
-	l.encoderFunc = nil
-	l.fields = nil
-	l.exporter = nil
-	l.errorOutput = nil
+	// Keep the level so that it's still safe to be checked after Close.
+	l.core.Store(&loggerCore{level: c.level, registry: c.registry})
+	l.mu.Unlock()
 
 	if len(errs) == 0 {
 		return nil
@@ -174,12 +366,24 @@ func (l *Logger) Close() error {
 }
 
 func (l *Logger) newEntry(level Level) *Entry {
-	if level >= l.level {
-		return newEntry(l, level)
+	c := l.load()
+	if c.enabled(level) {
+		return newEntry(l, c, level)
+	}
+	if level >= FatalLevel {
+		// The disabled entry is not exported, but it still terminates.
+		e := newEntry(l, c, level)
+		e.mute = true
+		return e
 	}
 	return nil
 }
 
+// Trace returns an Entry with TraceLevel.
+func (l *Logger) Trace() *Entry {
+	return l.newEntry(TraceLevel)
+}
+
 // Debug returns an Entry with DebugLevel.
 func (l *Logger) Debug() *Entry {
 	return l.newEntry(DebugLevel)
@@ -201,6 +405,22 @@ func (l *Logger) Error() *Entry {
 }
 
 // Fatal returns an Entry with FatalLevel.
+//
+// The exporter will be closed after the entry be exported, and
+// then the exitFunc is called with code 1 which terminates the process by default.
+// The exitFunc is called even if the entry is below the level and not exported.
+//
+// Notes: the exporter is shared by the parent and derived loggers,
+// so it's closed for all of them.
 func (l *Logger) Fatal() *Entry {
 	return l.newEntry(FatalLevel)
 }
+
+// Panic returns an Entry with PanicLevel.
+//
+// The panicFunc is called with the message after the entry be exported,
+// which panics by default. The panicFunc is called even if the entry
+// is below the level and not exported.
+func (l *Logger) Panic() *Entry {
+	return l.newEntry(PanicLevel)
+}
@@ -0,0 +1,72 @@
+package glog
+
+import (
+	"fmt"
+	"time"
+)
+
+// Builder used to build a child Logger with the fixed fields,
+// it's returned by Logger.With.
+//
+// The fields are added into the child only, the parent Logger is untouched;
+// The Builder should be disposed after Logger be called.
+type Builder struct {
+	core *loggerCore
+	// enc captures the fields for the fixedFields of child.
+	enc *recordEncoder
+}
+
+// With returns a Builder for creating a child Logger with more fixed fields.
+//
+// The child Logger shares the exporter, level and the other options with l,
+// e.g. for adding the request id per request:
+//
+//	l.With().Fields(func(oe ObjectEncoder) error {
+//		oe.AddString("request_id", id)
+//		return nil
+//	}).Logger().Info().Msg("hello").Fire()
+func (l *Logger) With() *Builder {
+	c := *l.load()
+	b := &Builder{
+		core: &c,
+		enc:  &recordEncoder{Encoder: c.encoderFunc()},
+	}
+	b.withError(b.enc.WriteIn(c.fields))
+	return b
+}
+
+// Fields adds the fields by fn into the child Logger, fn is called immediately;
+// The fields are dropped if fn returns an error.
+func (b *Builder) Fields(fn func(oe ObjectEncoder) error) *Builder {
+	rec := &recordEncoder{Encoder: b.core.encoderFunc()}
+	defer func() { _ = rec.Close() }()
+	if err := fn(rec); err != nil {
+		b.withError(err)
+		return b
+	}
+	b.withError(b.enc.WriteIn(rec.Bytes()))
+	b.enc.fields = append(b.enc.fields, rec.fields...)
+	return b
+}
+
+// Logger returns the child Logger with the fields.
+func (b *Builder) Logger() *Logger {
+	c := b.core
+	c.fields = append([]byte(nil), b.enc.Bytes()...)
+	c.fixedFields = append(append([]Field(nil), c.fixedFields...), b.enc.fields...)
+	b.withError(b.enc.Close())
+
+	l := &Logger{
+		isRoot: false,
+	}
+	l.core.Store(c)
+	return l
+}
+
+// withError handle any error if happen in builder inside.
+func (b *Builder) withError(err error) {
+	if err == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(b.core.errorOutput, "[glog] %s build logger error: %v\n", time.Now().Format(b.core.timeLayout), err)
+}
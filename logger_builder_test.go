@@ -0,0 +1,147 @@
+package glog
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_With(t *testing.T) {
+	var b bytes.Buffer
+	parent := NewDefault().WithExporter(StandardExporter(&b))
+	parent.WithFields().AddString("service", "api")
+
+	child := parent.With().
+		Fields(func(oe ObjectEncoder) error {
+			oe.AddString("request_id", "r-001")
+			oe.AddInt64("attempt", 2)
+			return oe.AddArray("tags", stringArray([]string{"a", "b"}))
+		}).
+		Fields(func(oe ObjectEncoder) error {
+			oe.AddDuration("timeout", time.Second, DurationFormatSecond)
+			return oe.AddObject("error", errorObject{err: errors.New("failed")})
+		}).
+		Logger()
+
+	child.Info().Msg("child").Fire()
+	require.Contains(t, b.String(), "[info] child service=api request_id=r-001 attempt=2 tags=[a b] timeout=1s "+
+		"error={msg=failed type=*errors.errorString}\n")
+
+	// The parent is untouched.
+	b.Reset()
+	parent.Info().Msg("parent").Fire()
+	require.Contains(t, b.String(), "[info] parent service=api\n")
+
+	// The grandchild.
+	b.Reset()
+	child.With().Fields(func(oe ObjectEncoder) error {
+		oe.AddString("user", "u1")
+		return nil
+	}).Logger().Info().Msg("grandchild").Fire()
+	require.Contains(t, b.String(), "service=api request_id=r-001 attempt=2")
+	require.Contains(t, b.String(), "user=u1\n")
+
+	// The level is shared with the parent.
+	b.Reset()
+	parent.WithLevel(WarnLevel)
+	child.Info().Msg("ignored").Fire()
+	require.Equal(t, "", b.String())
+	require.Equal(t, WarnLevel, child.Level())
+
+	// The child is not a root logger, so Close does not close the exporter.
+	require.False(t, child.isRoot)
+}
+
+func TestLogger_With_Error(t *testing.T) {
+	var b, eb bytes.Buffer
+	parent := NewDefault().WithExporter(StandardExporter(&b)).WithErrorOutput(&eb)
+
+	child := parent.With().Fields(func(oe ObjectEncoder) error {
+		oe.AddString("k", "v")
+		return errors.New("marshal failed")
+	}).Logger()
+	require.Contains(t, eb.String(), "build logger error: marshal failed")
+
+	// The fields of the failed call are dropped.
+	child.Info().Msg("child").Fire()
+	require.Contains(t, b.String(), "[info] child\n")
+
+	// The fields of the other calls are kept.
+	child = parent.With().Fields(func(oe ObjectEncoder) error {
+		oe.AddString("k1", "v1")
+		return nil
+	}).Fields(func(oe ObjectEncoder) error {
+		oe.AddString("k2", "v2")
+		return errors.New("marshal failed")
+	}).Fields(func(oe ObjectEncoder) error {
+		oe.AddString("k3", "v3")
+		return nil
+	}).Logger()
+	child.Info().Msg("child").Fire()
+	require.Contains(t, b.String(), "[info] child k1=v1 k3=v3\n")
+}
+
+func TestLogger_With_RecordFields(t *testing.T) {
+	export := &CustomExporter{}
+	parent := NewDefault().WithExporter(export).WithEncoderFunc(JSONEncoder).WithRecordFields(true)
+	parent.WithFields().AddString("service", "api")
+
+	child := parent.With().Fields(func(oe ObjectEncoder) error {
+		oe.AddString("request_id", "r-001")
+		return nil
+	}).Logger()
+	child.Info().Int("code", 200).Fire()
+	require.Equal(t, `{"time":"`, string(export.record.Bytes()[:9]))
+	require.Contains(t, string(export.record.Bytes()), `"code":200,"service":"api","request_id":"r-001"}`)
+
+	var keys []string
+	for _, f := range export.record.Fields() {
+		keys = append(keys, f.Key)
+	}
+	require.Equal(t, []string{"code", "service", "request_id"}, keys)
+}
+
+func TestLogger_With_Concurrency(t *testing.T) {
+	var mu sync.Mutex
+	var b bytes.Buffer
+	parent := NewDefault().WithExporter(StandardExporter(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return b.Write(p)
+	})))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := strconv.Itoa(i)
+			l := parent.With().Fields(func(oe ObjectEncoder) error {
+				oe.AddString("request_id", id)
+				return nil
+			}).Logger()
+			for j := 0; j < 10; j++ {
+				l.Info().Msg("request").String("id", id).Fire()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	require.Equal(t, 80, len(lines))
+	for _, line := range lines {
+		i := strings.Index(line, " id=")
+		id := line[i+4 : i+5]
+		require.True(t, strings.HasSuffix(line, "request_id="+id), line)
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,95 @@
+package glog
+
+import (
+	"runtime"
+	"time"
+)
+
+var _ Encoder = (*fieldsEncoder)(nil)
+
+// fieldsEncoder implements Encoder for adding the fixed fields of Logger,
+// every call appends the encoded data to the fixed fields.
+type fieldsEncoder struct {
+	l *Logger
+}
+
+// Bytes returns the encoded fixed fields of Logger, it must not be modified.
+func (fe *fieldsEncoder) Bytes() []byte {
+	return fe.l.load().fields
+}
+
+// Close does nothing, the fixed fields are kept by Logger; Use ResetFields to clear them.
+func (fe *fieldsEncoder) Close() error {
+	return nil
+}
+
+// AddMsg Implements BuildEncoder.
+func (fe *fieldsEncoder) AddMsg(msg string) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddMsg(msg); return nil })
+}
+func (fe *fieldsEncoder) AddEntryTime(t time.Time, layout string) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddEntryTime(t, layout); return nil })
+}
+func (fe *fieldsEncoder) AddLevel(level Level) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddLevel(level); return nil })
+}
+func (fe *fieldsEncoder) AddCaller(frame runtime.Frame, layout int8) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddCaller(frame, layout); return nil })
+}
+func (fe *fieldsEncoder) AddBeginMarker() {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddBeginMarker(); return nil })
+}
+func (fe *fieldsEncoder) AddEndMarker() {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddEndMarker(); return nil })
+}
+func (fe *fieldsEncoder) AddLineBreak() {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddLineBreak(); return nil })
+}
+func (fe *fieldsEncoder) WriteIn(p []byte) error {
+	return fe.l.addFields(func(enc Encoder) error { return enc.WriteIn(p) })
+}
+
+// AddByte Implements ObjectEncoder.
+
+func (fe *fieldsEncoder) AddByte(k string, b byte) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddByte(k, b); return nil })
+}
+func (fe *fieldsEncoder) AddString(k string, s string) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddString(k, s); return nil })
+}
+func (fe *fieldsEncoder) AddBool(k string, v bool) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddBool(k, v); return nil })
+}
+func (fe *fieldsEncoder) AddInt64(k string, i int64) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddInt64(k, i); return nil })
+}
+func (fe *fieldsEncoder) AddUnt64(k string, i uint64) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddUnt64(k, i); return nil })
+}
+func (fe *fieldsEncoder) AddFloat64(k string, f float64) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddFloat64(k, f); return nil })
+}
+func (fe *fieldsEncoder) AddComplex128(k string, c complex128) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddComplex128(k, c); return nil })
+}
+func (fe *fieldsEncoder) AddRawBytes(k string, bs []byte) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddRawBytes(k, bs); return nil })
+}
+func (fe *fieldsEncoder) AddRawString(k string, s string) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddRawString(k, s); return nil })
+}
+func (fe *fieldsEncoder) AddTime(k string, t time.Time, layout string) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddTime(k, t, layout); return nil })
+}
+func (fe *fieldsEncoder) AddDuration(k string, d time.Duration, layout int8) {
+	_ = fe.l.addFields(func(enc Encoder) error { enc.AddDuration(k, d, layout); return nil })
+}
+func (fe *fieldsEncoder) AddArray(k string, am ArrayMarshaler) error {
+	return fe.l.addFields(func(enc Encoder) error { return enc.AddArray(k, am) })
+}
+func (fe *fieldsEncoder) AddObject(k string, om ObjectMarshaler) error {
+	return fe.l.addFields(func(enc Encoder) error { return enc.AddObject(k, om) })
+}
+func (fe *fieldsEncoder) AddInterface(k string, i interface{}) error {
+	return fe.l.addFields(func(enc Encoder) error { return enc.AddInterface(k, i) })
+}
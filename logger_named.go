@@ -0,0 +1,137 @@
+package glog
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// loggerNameKey is the field key of the logger's name.
+const loggerNameKey = "logger"
+
+// levelRegistry holds the levels set by name prefix, it's shared by the
+// cloned and named loggers.
+type levelRegistry struct {
+	mu     sync.RWMutex
+	levels map[string]Level
+
+	// version is increased on every change, accessed atomically.
+	version uint64
+}
+
+func newLevelRegistry() *levelRegistry {
+	return &levelRegistry{
+		levels:  make(map[string]Level),
+		version: 1,
+	}
+}
+
+func (r *levelRegistry) set(prefix string, level Level) {
+	r.mu.Lock()
+	r.levels[prefix] = level
+	atomic.AddUint64(&r.version, 1)
+	r.mu.Unlock()
+}
+
+func (r *levelRegistry) unset(prefix string) {
+	r.mu.Lock()
+	delete(r.levels, prefix)
+	atomic.AddUint64(&r.version, 1)
+	r.mu.Unlock()
+}
+
+// lookup returns the level of the longest prefix that matches name and the
+// current version; The prefix matches the name itself and its descendants,
+// e.g. "app.db" matches "app.db" and "app.db.pool" but not "app.dbx".
+func (r *levelRegistry) lookup(name string) (level Level, ok bool, version uint64) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	version = atomic.LoadUint64(&r.version)
+	for {
+		if level, ok = r.levels[name]; ok {
+			return
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return
+		}
+		name = name[:i]
+	}
+}
+
+// namedLevel caches the level in registry for a named logger, the cached
+// value packs the version of registry, whether the level is set, and the level.
+type namedLevel struct {
+	v uint64
+}
+
+const (
+	namedLevelOK    = 1 << 8
+	namedLevelShift = 9
+)
+
+// level returns the level of name in registry, ok is false if not set.
+func (nl *namedLevel) level(r *levelRegistry, name string) (Level, bool) {
+	v := atomic.LoadUint64(&nl.v)
+	if v>>namedLevelShift != atomic.LoadUint64(&r.version) {
+		level, ok, version := r.lookup(name)
+		v = version<<namedLevelShift | uint64(uint8(level))
+		if ok {
+			v |= namedLevelOK
+		}
+		atomic.StoreUint64(&nl.v, v)
+	}
+	return Level(int8(uint8(v))), v&namedLevelOK != 0
+}
+
+// enabled returns true if the entry with level should be logged.
+func (c *loggerCore) enabled(level Level) bool {
+	if c.name != "" {
+		if lv, ok := c.namedLevel.level(c.registry, c.name); ok {
+			return level.rank() >= lv.rank()
+		}
+	}
+	return level.rank() >= c.level.Level().rank()
+}
+
+// Named returns a child Logger with the name appended to l's name by a
+// dot, e.g. "app.db.pool"; The name is added as the field "logger".
+//
+// The level of the child can be set by WithNamedLevel, otherwise the
+// level of l is used.
+func (l *Logger) Named(name string) *Logger {
+	nl := l.Clone()
+	if name == "" {
+		return nl
+	}
+	return nl.update(func(c *loggerCore) {
+		if c.name == "" {
+			c.name = name
+		} else {
+			c.name = c.name + "." + name
+		}
+		c.namedLevel = &namedLevel{}
+	})
+}
+
+// Name returns the logger's name, it's empty if the logger is not named.
+func (l *Logger) Name() string {
+	return l.load().name
+}
+
+// WithNamedLevel sets the level for the named loggers whose name is prefix
+// or starts with prefix followed by a dot, the longest prefix takes effect.
+//
+// It takes effect at runtime on all loggers that derived from the same root
+// Logger, and overrides their level.
+func (l *Logger) WithNamedLevel(prefix string, level Level) *Logger {
+	l.load().registry.set(prefix, level)
+	return l
+}
+
+// ResetNamedLevel removes the level set by WithNamedLevel with the prefix.
+func (l *Logger) ResetNamedLevel(prefix string) *Logger {
+	l.load().registry.unset(prefix)
+	return l
+}
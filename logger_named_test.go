@@ -0,0 +1,118 @@
+package glog
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_Named(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithEncoderFunc(JSONEncoder)
+	l.WithFields().AddString("service", "api")
+
+	app := l.Named("app")
+	db := app.Named("db")
+	pool := db.Named("pool")
+	require.Equal(t, "", l.Name())
+	require.Equal(t, "app", app.Name())
+	require.Equal(t, "app.db", db.Name())
+	require.Equal(t, "app.db.pool", pool.Name())
+	require.Equal(t, "app.db", db.Named("").Name())
+
+	pool.Info().Msg("hello").Fire()
+	require.Contains(t, b.String(), `"message":"hello","logger":"app.db.pool","service":"api"}`)
+
+	// The parent is untouched.
+	b.Reset()
+	l.Info().Msg("hello").Fire()
+	require.NotContains(t, b.String(), `"logger"`)
+}
+
+func TestLogger_WithNamedLevel(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithLevel(InfoLevel)
+	db := l.Named("app").Named("db")
+	pool := db.Named("pool")
+	dbx := l.Named("app").Named("dbx")
+	cache := l.Named("app").Named("cache")
+
+	logAll := func() {
+		b.Reset()
+		for _, x := range []*Logger{l, db, pool, dbx, cache} {
+			x.Debug().Msg("debug").Fire()
+		}
+	}
+	count := func() int { return bytes.Count(b.Bytes(), []byte("[debug]")) }
+
+	logAll()
+	require.Equal(t, 0, count())
+
+	// Turn on debug for the subsystem and its descendants.
+	l.WithNamedLevel("app.db", DebugLevel)
+	logAll()
+	require.Equal(t, 2, count())
+	require.Contains(t, b.String(), "logger=app.db\n")
+	require.Contains(t, b.String(), "logger=app.db.pool\n")
+
+	// The longest prefix takes effect.
+	db.WithNamedLevel("app.db.pool", ErrorLevel)
+	logAll()
+	require.Equal(t, 1, count())
+	b.Reset()
+	pool.Warn().Msg("warn").Fire()
+	require.Equal(t, "", b.String())
+
+	// The named level overrides the level of logger.
+	l.WithNamedLevel("app", FatalLevel)
+	b.Reset()
+	cache.Error().Msg("error").Fire()
+	require.Equal(t, "", b.String())
+	l.Error().Msg("error").Fire()
+	require.Contains(t, b.String(), "[error] error")
+
+	l.ResetNamedLevel("app").ResetNamedLevel("app.db").ResetNamedLevel("app.db.pool")
+	logAll()
+	require.Equal(t, 0, count())
+	l.WithLevel(DebugLevel)
+	logAll()
+	require.Equal(t, 5, count())
+
+	// The registry is not shared between the root loggers.
+	NewDefault().WithNamedLevel("app", FatalLevel)
+	b.Reset()
+	db.Debug().Msg("debug").Fire()
+	require.Equal(t, 1, count())
+}
+
+func TestLogger_WithNamedLevel_Concurrency(t *testing.T) {
+	var exported uint64
+	l := NewDefault().WithExporter(&countExporter{n: &exported}).WithLevel(InfoLevel)
+	db := l.Named("db")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				db.Debug().Msg("debug").Fire()
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		if i%2 == 0 {
+			l.WithNamedLevel("db", DebugLevel)
+		} else {
+			l.ResetNamedLevel("db")
+		}
+	}
+	wg.Wait()
+
+	l.WithNamedLevel("db", DebugLevel)
+	require.True(t, db.load().enabled(DebugLevel))
+	l.ResetNamedLevel("db")
+	require.False(t, db.load().enabled(DebugLevel))
+}
@@ -9,7 +9,10 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,14 +21,15 @@ import (
 
 func TestLoggerNewDefault(t *testing.T) {
 	l := NewDefault()
-	require.Equal(t, l.ctx, context.Background())
-	require.Equal(t, l.level, DebugLevel)
-	require.Equal(t, l.timeLayout, defaultTimeLayout)
-	require.False(t, l.caller)
-	require.True(t, reflect.DeepEqual(l.exporter, DefaultExporter))
-	require.True(t, reflect.DeepEqual(l.encoderFunc(), TextEncoder()))
-	require.True(t, reflect.DeepEqual(l.fields, TextEncoder()))
-	require.True(t, reflect.DeepEqual(l.errorOutput, os.Stderr))
+	c := l.load()
+	require.Equal(t, c.ctx, context.Background())
+	require.Equal(t, l.Level(), DebugLevel)
+	require.Equal(t, c.timeLayout, defaultTimeLayout)
+	require.False(t, c.caller)
+	require.True(t, reflect.DeepEqual(c.exporter, DefaultExporter))
+	require.True(t, reflect.DeepEqual(c.encoderFunc(), TextEncoder()))
+	require.Equal(t, len(c.fields), 0)
+	require.True(t, reflect.DeepEqual(c.errorOutput, os.Stderr))
 }
 
 type loggerWithContext struct {
@@ -47,8 +51,8 @@ func TestLogger_WithContext(t *testing.T) {
 	exporter := &loggerWithContext{}
 
 	l := NewDefault().WithContext(ctx).WithExporter(exporter)
-	require.Equal(t, l.ctx, ctx)
-	require.True(t, reflect.DeepEqual(l.ctx, ctx))
+	require.Equal(t, l.Context(), ctx)
+	require.True(t, reflect.DeepEqual(l.Context(), ctx))
 
 	l.Error().Msg("Hello World").Fire()
 	require.Equal(t, exporter.record.Level(), ErrorLevel)
@@ -68,6 +72,26 @@ func TestLogger_WithLevel(t *testing.T) {
 	require.Equal(t, len(b.Bytes()), 0)
 }
 
+func TestLogger_Trace(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b))
+
+	// The default level is DebugLevel.
+	l.Trace().Msg("TraceMessage1").Fire()
+	require.Equal(t, len(b.Bytes()), 0)
+
+	l.WithLevel(TraceLevel)
+	l.Trace().Msg("TraceMessage2").Fire()
+	l.Debug().Msg("DebugMessage").Fire()
+	require.Contains(t, b.String(), "[trace] TraceMessage2")
+	require.Contains(t, b.String(), "[debug] DebugMessage")
+
+	b.Reset()
+	l.WithEncoderFunc(JSONEncoder)
+	l.Trace().Msg("TraceMessage3").Fire()
+	require.Contains(t, b.String(), `"level":"trace"`)
+}
+
 func TestLogger_WithCaller(t *testing.T) {
 	var b bytes.Buffer
 	l := NewDefault().WithExporter(StandardExporter(&b)).WithCaller(true)
@@ -83,6 +107,53 @@ func TestLogger_WithCaller(t *testing.T) {
 	require.Equal(t, strings.Count(b.String(), file), 2)
 }
 
+// logWrapper used to test the caller skip.
+func logWrapper(l *Logger, msg string) {
+	l.Info().Msg(msg).Fire()
+}
+
+func TestLogger_WithCallerSkip(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithCaller(true)
+
+	_, file, line, ok := runtime.Caller(0)
+	require.True(t, ok)
+
+	l.WithCallerSkip(1)
+	logWrapper(l, "skip logger")
+	require.Contains(t, b.String(), file+":"+strconv.Itoa(line+4))
+
+	b.Reset()
+	l.WithCallerSkip(0)
+	l.Info().CallerSkip(1).Msg("skip entry").Fire()
+	require.NotContains(t, b.String(), file)
+}
+
+func TestLogger_WithCallerFormat(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithEncoderFunc(JSONEncoder).
+		WithCaller(true).WithCallerFunc(true).WithCallerFormat(CallerFormatShort)
+
+	_, file, line, ok := runtime.Caller(0)
+	require.True(t, ok)
+	l.Info().Msg("short caller").Fire()
+
+	short := CallerFile(file, CallerFormatShort)
+	require.True(t, strings.HasSuffix(short, "/logger_test.go"))
+	require.NotContains(t, short[:len(short)-len("/logger_test.go")], "/")
+
+	var m map[string]interface{}
+	require.Nil(t, json.Unmarshal(b.Bytes(), &m))
+	require.Equal(t, short+":"+strconv.Itoa(line+2), m["caller"])
+	require.Equal(t, "glog.TestLogger_WithCallerFormat", m["func"])
+
+	b.Reset()
+	l.WithEncoderFunc(TextEncoder).WithCallerFunc(false)
+	l.Info().Msg("short caller").Fire()
+	require.Contains(t, b.String(), "("+short+":")
+	require.NotContains(t, b.String(), "TestLogger_WithCallerFormat")
+}
+
 func TestLogger_WithFields(t *testing.T) {
 	var b bytes.Buffer
 	l := NewDefault().WithExporter(StandardExporter(&b))
@@ -103,9 +174,32 @@ func TestLogger_WithFields(t *testing.T) {
 	require.Equal(t, strings.Count(s, "dup-key"), 2)
 }
 
+func TestLogger_WithFields_Error(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithEncoderFunc(JSONEncoder).WithExporter(StandardExporter(&b))
+
+	fields := l.WithFields()
+	fields.AddString("a", "1")
+	require.Nil(t, fields.WriteIn([]byte(`"b":2`)))
+	require.Equal(t, `"a":"1","b":2`, string(fields.Bytes()))
+
+	// The fields written before the error are discarded.
+	err := fields.AddObject("obj", ObjectMarshalerFunc(func(oe ObjectEncoder) error {
+		oe.AddString("x", "y")
+		return errors.New("marshal failed")
+	}))
+	require.NotNil(t, err)
+	require.Equal(t, `"a":"1","b":2`, string(fields.Bytes()))
+	require.Nil(t, fields.Close())
+
+	l.Info().Msg("hello").Fire()
+	require.Contains(t, b.String(), `"message":"hello","a":"1","b":2}`)
+	require.Equal(t, "", string(l.ResetFields().Bytes()))
+}
+
 func TestLoggerWithTextEncoder(t *testing.T) {
 	var eb bytes.Buffer
-	l := NewDefault().WithCaller(true).WithErrorOutput(&eb)
+	l := NewDefault().WithCaller(true).WithErrorOutput(&eb).WithExitFunc(func(code int) {})
 
 	l.Info().Msg("HelloWorld").Fire()
 
@@ -191,26 +285,26 @@ func TestLogger_Clone(t *testing.T) {
 	require.True(t, l.isRoot)
 
 	nl := l.Clone()
-	require.Equal(t, l.ctx, nl.ctx)
-	require.Equal(t, l.level, nl.level)
-	require.Equal(t, l.timeLayout, nl.timeLayout)
-	require.Equal(t, l.caller, nl.caller)
-	require.Equal(t, reflect.ValueOf(l.exporter).Pointer(), reflect.ValueOf(nl.exporter).Pointer())
-	require.Equal(t, reflect.ValueOf(l.encoderFunc).Pointer(), reflect.ValueOf(nl.encoderFunc).Pointer())
-	require.Equal(t, reflect.ValueOf(l.errorOutput).Pointer(), reflect.ValueOf(nl.errorOutput).Pointer())
-	require.NotEqual(t, reflect.ValueOf(l.fields).Pointer(), reflect.ValueOf(nl.fields).Pointer())
-	require.Equal(t, l.fields.Bytes(), nl.fields.Bytes())
+	c, nc := l.load(), nl.load()
+	require.Equal(t, c.ctx, nc.ctx)
+	require.Equal(t, l.Level(), nl.Level())
+	require.Equal(t, c.timeLayout, nc.timeLayout)
+	require.Equal(t, c.caller, nc.caller)
+	require.Equal(t, reflect.ValueOf(c.exporter).Pointer(), reflect.ValueOf(nc.exporter).Pointer())
+	require.Equal(t, reflect.ValueOf(c.encoderFunc).Pointer(), reflect.ValueOf(nc.encoderFunc).Pointer())
+	require.Equal(t, reflect.ValueOf(c.errorOutput).Pointer(), reflect.ValueOf(nc.errorOutput).Pointer())
+	require.Equal(t, c.fields, nc.fields)
 	require.False(t, nl.isRoot)
 
 	// Reset the context in new logger.
 	ctx2 := context.WithValue(context.Background(), ctxKey{}, "v2")
 	nl.WithContext(ctx2)
-	require.Equal(t, l.ctx, ctx1)
-	require.True(t, reflect.DeepEqual(l.ctx, ctx1))
-	require.Equal(t, nl.ctx, ctx2)
-	require.True(t, reflect.DeepEqual(nl.ctx, ctx2))
-	require.NotEqual(t, l.ctx, nl.ctx)
-	require.False(t, reflect.DeepEqual(l.ctx, nl.ctx))
+	require.Equal(t, l.Context(), ctx1)
+	require.True(t, reflect.DeepEqual(l.Context(), ctx1))
+	require.Equal(t, nl.Context(), ctx2)
+	require.True(t, reflect.DeepEqual(nl.Context(), ctx2))
+	require.NotEqual(t, l.Context(), nl.Context())
+	require.False(t, reflect.DeepEqual(l.Context(), nl.Context()))
 
 	nl.WithFields().AddString("filed-k2", "filed-v2")
 	b.Reset()
@@ -247,6 +341,114 @@ func TestLogger_Clone(t *testing.T) {
 	require.Equal(t, eb.Len(), 0)
 }
 
+func TestLogger_Fatal(t *testing.T) {
+	var eb bytes.Buffer
+	writer := &loggerWriterCloser{}
+	exitCode := -1
+
+	l := NewDefault().WithExporter(StandardExporter(writer)).WithErrorOutput(&eb).
+		WithExitFunc(func(code int) {
+			// The exporter must be closed before exit.
+			require.True(t, writer.isClosed)
+			exitCode = code
+		})
+
+	l.Error().Msg("error message").Fire()
+	require.Equal(t, -1, exitCode)
+	require.False(t, writer.isClosed)
+
+	l.Fatal().Msg("fatal message").Fire()
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, string(writer.data), "fatal message")
+	require.Equal(t, eb.Len(), 0)
+
+	// The disabled fatal entry is not exported but still exits.
+	exitCode = -1
+	writer.resetData()
+	l.WithLevel(PanicLevel).Fatal().Msg("fatal message").Fire()
+	require.Equal(t, 1, exitCode)
+	require.Equal(t, 0, len(writer.data))
+}
+
+func TestLogger_Panic(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b))
+
+	require.PanicsWithValue(t, "panic message", func() {
+		l.Panic().Msg("panic message").Fire()
+	})
+	require.Contains(t, b.String(), "[panic] panic message")
+
+	var v interface{}
+	l.WithPanicFunc(func(i interface{}) { v = i })
+	require.NotPanics(t, func() {
+		l.Panic().Msg("recovered message").Fire()
+	})
+	require.Equal(t, "recovered message", v)
+}
+
+type loggerCountExporter struct {
+	n int64
+}
+
+func (exp *loggerCountExporter) Export(record *Record) error {
+	atomic.AddInt64(&exp.n, 1)
+	return nil
+}
+
+func (exp *loggerCountExporter) Close() error {
+	return nil
+}
+
+func TestLogger_Concurrency(t *testing.T) {
+	type ctxKey struct{}
+
+	var eb bytes.Buffer
+	exp1 := &loggerCountExporter{}
+	exp2 := &loggerCountExporter{}
+	l := NewDefault().WithExporter(exp1).WithErrorOutput(&eb)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				l.Debug().Msg("HelloWorld").String("k", "v").Fire()
+				nl := l.Clone()
+				nl.WithFields().AddString("clone", "v")
+				nl.Info().Msg("HelloClone").Fire()
+
+				select {
+				case <-stop:
+					return
+				default:
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		l.WithLevel(InfoLevel)
+		l.WithExporter(exp2)
+		l.WithFields().AddInt64("i", int64(i))
+		l.WithContext(context.WithValue(context.Background(), ctxKey{}, i))
+		l.WithCaller(i%2 == 0)
+		l.WithTimeLayout(time.RFC3339)
+		l.WithEncoderFunc(JSONEncoder)
+		l.WithLevel(DebugLevel)
+		l.WithExporter(exp1)
+		l.ResetFields()
+		l.WithEncoderFunc(TextEncoder)
+	}
+	close(stop)
+	wg.Wait()
+
+	require.Greater(t, atomic.LoadInt64(&exp1.n)+atomic.LoadInt64(&exp2.n), int64(0))
+	require.Equal(t, eb.Len(), 0)
+}
+
 type loggerWriterCloser struct {
 	data     []byte
 	isClosed bool
@@ -2,6 +2,8 @@ package glog
 
 import (
 	"context"
+	"runtime"
+	"time"
 )
 
 // Record represents the Entry's content.
@@ -9,6 +11,26 @@ type Record struct {
 	ctx   context.Context
 	level Level
 	data  []byte
+
+	time time.Time
+	msg  string
+
+	// ext is nil unless the caller, the fields or the EncoderExporters are used,
+	// so that they cost nothing otherwise.
+	ext *recordExt
+}
+
+// recordExt is the optional content of Record, it's shared by the alts.
+type recordExt struct {
+	fields []Field
+
+	caller    runtime.Frame
+	hasCaller bool
+
+	// keys and alts is the records encoded by the EncoderFunc of Logger and
+	// the EncoderExporters, keys is the EncoderExporters and nil for the Logger.
+	keys []*encoderExporter
+	alts []Record
 }
 
 // Context returns context where in Logger.
@@ -21,6 +43,58 @@ func (r *Record) Level() Level {
 	return r.level
 }
 
+// Time returns the time when the entry created.
+func (r *Record) Time() time.Time {
+	return r.time
+}
+
+// Message returns the message of the entry.
+func (r *Record) Message() string {
+	return r.msg
+}
+
+// Caller returns the caller of the entry, ok is false if the caller is not enabled.
+// The frame.Function is empty unless WithCallerFunc(true) be set.
+func (r *Record) Caller() (frame runtime.Frame, ok bool) {
+	if r.ext == nil || !r.ext.hasCaller {
+		return frame, false
+	}
+	return r.ext.caller, true
+}
+
+// encodedBy returns the record encoded for exp, or r itself if not found.
+func (r *Record) encodedBy(exp *encoderExporter) *Record {
+	if r.ext == nil {
+		return r
+	}
+	for i := range r.ext.keys {
+		if r.ext.keys[i] == exp {
+			return &r.ext.alts[i]
+		}
+	}
+	return r
+}
+
+// extension returns the ext of r, and creates it if nil.
+func (r *Record) extension() *recordExt {
+	if r.ext == nil {
+		r.ext = &recordExt{}
+	}
+	return r.ext
+}
+
+// Fields returns the top-level fields of the entry followed by the fixed fields of Logger,
+// in the order they were added. It returns nil unless WithRecordFields(true) be set.
+//
+// NOTICE: The values referenced by fields, e.g. the []byte and the marshalers,
+// are owned by the caller of Entry. They are retained as is by the Record.
+func (r *Record) Fields() []Field {
+	if r.ext == nil {
+		return nil
+	}
+	return r.ext.fields
+}
+
 // Bytes returns the Entry's content.
 func (r *Record) Bytes() []byte {
 	return r.data
@@ -32,3 +106,32 @@ func (r *Record) Copy() []byte {
 	copy(bs, r.data)
 	return bs
 }
+
+// clone returns a copy of the Record that can be retained after Export returns.
+func (r *Record) clone() *Record {
+	nr := *r
+	nr.data = r.Copy()
+	if r.ext == nil {
+		return &nr
+	}
+	ext := *r.ext
+	if ext.fields != nil {
+		ext.fields = make([]Field, len(r.ext.fields))
+		copy(ext.fields, r.ext.fields)
+		for i := range ext.fields {
+			if bs, ok := ext.fields[i].Interface.([]byte); ok && ext.fields[i].Type == FieldTypeRawBytes {
+				ext.fields[i].Interface = append([]byte(nil), bs...)
+			}
+		}
+	}
+	if ext.alts != nil {
+		ext.alts = make([]Record, len(r.ext.alts))
+		for i := range r.ext.alts {
+			ext.alts[i] = r.ext.alts[i]
+			ext.alts[i].data = r.ext.alts[i].Copy()
+			ext.alts[i].ext = &ext
+		}
+	}
+	nr.ext = &ext
+	return &nr
+}
@@ -0,0 +1,181 @@
+package glog
+
+import (
+	"math"
+	"time"
+)
+
+// FieldType indicates the type of the value in Field.
+type FieldType uint8
+
+const (
+	FieldTypeUnknown FieldType = iota
+	FieldTypeByte
+	FieldTypeString
+	FieldTypeBool
+	FieldTypeInt64
+	FieldTypeUint64
+	FieldTypeFloat64
+	FieldTypeComplex128
+	FieldTypeRawBytes
+	FieldTypeRawString
+	FieldTypeTime
+	FieldTypeDuration
+	FieldTypeArray
+	FieldTypeObject
+	FieldTypeInterface
+)
+
+// Field is a top-level k/v field of the entry captured for Record.
+//
+// The value is stored in one of Integer, String and Interface by the Type,
+// use Value to get it in the original type. The arrays and objects are kept
+// as the ArrayMarshaler and ObjectMarshaler, they are not expanded.
+type Field struct {
+	Key  string
+	Type FieldType
+
+	// Integer holds the value of byte, bool, int64, duration, and the bits of uint64 and float64.
+	Integer int64
+	// String holds the value of string and raw string, and the layout of time.
+	String string
+	// Interface holds the value of other types, and the layout of duration.
+	Interface interface{}
+}
+
+// Value returns the value of field in the original type, i.e. byte, string,
+// bool, int64, uint64, float64, complex128, []byte, time.Time, time.Duration,
+// ArrayMarshaler, ObjectMarshaler or the value added by Any.
+func (f *Field) Value() interface{} {
+	switch f.Type {
+	case FieldTypeByte:
+		return byte(f.Integer)
+	case FieldTypeString, FieldTypeRawString:
+		return f.String
+	case FieldTypeBool:
+		return f.Integer == 1
+	case FieldTypeInt64:
+		return f.Integer
+	case FieldTypeUint64:
+		return uint64(f.Integer)
+	case FieldTypeFloat64:
+		return math.Float64frombits(uint64(f.Integer))
+	case FieldTypeDuration:
+		return time.Duration(f.Integer)
+	default:
+		return f.Interface
+	}
+}
+
+// encode adds the field into enc.
+func (f *Field) encode(enc ObjectEncoder) error {
+	switch f.Type {
+	case FieldTypeByte:
+		enc.AddByte(f.Key, byte(f.Integer))
+	case FieldTypeString:
+		enc.AddString(f.Key, f.String)
+	case FieldTypeBool:
+		enc.AddBool(f.Key, f.Integer == 1)
+	case FieldTypeInt64:
+		enc.AddInt64(f.Key, f.Integer)
+	case FieldTypeUint64:
+		enc.AddUnt64(f.Key, uint64(f.Integer))
+	case FieldTypeFloat64:
+		enc.AddFloat64(f.Key, math.Float64frombits(uint64(f.Integer)))
+	case FieldTypeComplex128:
+		enc.AddComplex128(f.Key, f.Interface.(complex128))
+	case FieldTypeRawBytes:
+		enc.AddRawBytes(f.Key, f.Interface.([]byte))
+	case FieldTypeRawString:
+		enc.AddRawString(f.Key, f.String)
+	case FieldTypeTime:
+		enc.AddTime(f.Key, f.Interface.(time.Time), f.String)
+	case FieldTypeDuration:
+		enc.AddDuration(f.Key, time.Duration(f.Integer), f.Interface.(int8))
+	case FieldTypeArray:
+		return enc.AddArray(f.Key, f.Interface.(ArrayMarshaler))
+	case FieldTypeObject:
+		return enc.AddObject(f.Key, f.Interface.(ObjectMarshaler))
+	default:
+		return enc.AddInterface(f.Key, f.Interface)
+	}
+	return nil
+}
+
+var _ Encoder = (*recordEncoder)(nil)
+
+// recordEncoder wraps an Encoder and captures the top-level fields for Record.
+type recordEncoder struct {
+	Encoder
+	fields []Field
+}
+
+// AddStack implements StackEncoder.
+func (enc *recordEncoder) AddStack(pcs []uintptr) {
+	addStack(enc.Encoder, pcs)
+}
+
+func (enc *recordEncoder) add(f Field) {
+	enc.fields = append(enc.fields, f)
+}
+
+func (enc *recordEncoder) AddByte(k string, b byte) {
+	enc.add(Field{Key: k, Type: FieldTypeByte, Integer: int64(b)})
+	enc.Encoder.AddByte(k, b)
+}
+func (enc *recordEncoder) AddString(k string, s string) {
+	enc.add(Field{Key: k, Type: FieldTypeString, String: s})
+	enc.Encoder.AddString(k, s)
+}
+func (enc *recordEncoder) AddBool(k string, v bool) {
+	var i int64
+	if v {
+		i = 1
+	}
+	enc.add(Field{Key: k, Type: FieldTypeBool, Integer: i})
+	enc.Encoder.AddBool(k, v)
+}
+func (enc *recordEncoder) AddInt64(k string, i int64) {
+	enc.add(Field{Key: k, Type: FieldTypeInt64, Integer: i})
+	enc.Encoder.AddInt64(k, i)
+}
+func (enc *recordEncoder) AddUnt64(k string, i uint64) {
+	enc.add(Field{Key: k, Type: FieldTypeUint64, Integer: int64(i)})
+	enc.Encoder.AddUnt64(k, i)
+}
+func (enc *recordEncoder) AddFloat64(k string, f float64) {
+	enc.add(Field{Key: k, Type: FieldTypeFloat64, Integer: int64(math.Float64bits(f))})
+	enc.Encoder.AddFloat64(k, f)
+}
+func (enc *recordEncoder) AddComplex128(k string, c complex128) {
+	enc.add(Field{Key: k, Type: FieldTypeComplex128, Interface: c})
+	enc.Encoder.AddComplex128(k, c)
+}
+func (enc *recordEncoder) AddRawBytes(k string, bs []byte) {
+	enc.add(Field{Key: k, Type: FieldTypeRawBytes, Interface: bs})
+	enc.Encoder.AddRawBytes(k, bs)
+}
+func (enc *recordEncoder) AddRawString(k string, s string) {
+	enc.add(Field{Key: k, Type: FieldTypeRawString, String: s})
+	enc.Encoder.AddRawString(k, s)
+}
+func (enc *recordEncoder) AddTime(k string, t time.Time, layout string) {
+	enc.add(Field{Key: k, Type: FieldTypeTime, String: layout, Interface: t})
+	enc.Encoder.AddTime(k, t, layout)
+}
+func (enc *recordEncoder) AddDuration(k string, d time.Duration, layout int8) {
+	enc.add(Field{Key: k, Type: FieldTypeDuration, Integer: int64(d), Interface: layout})
+	enc.Encoder.AddDuration(k, d, layout)
+}
+func (enc *recordEncoder) AddArray(k string, am ArrayMarshaler) error {
+	enc.add(Field{Key: k, Type: FieldTypeArray, Interface: am})
+	return enc.Encoder.AddArray(k, am)
+}
+func (enc *recordEncoder) AddObject(k string, om ObjectMarshaler) error {
+	enc.add(Field{Key: k, Type: FieldTypeObject, Interface: om})
+	return enc.Encoder.AddObject(k, om)
+}
+func (enc *recordEncoder) AddInterface(k string, i interface{}) error {
+	enc.add(Field{Key: k, Type: FieldTypeInterface, Interface: i})
+	return enc.Encoder.AddInterface(k, i)
+}
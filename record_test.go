@@ -0,0 +1,99 @@
+package glog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord_Metadata(t *testing.T) {
+	export := &CustomExporter{}
+	l := NewDefault().WithExporter(export).WithCaller(true).WithCallerFunc(true)
+
+	before := time.Now()
+	l.Info().Msg("hello").Int("code", 200).Fire()
+	require.Equal(t, "hello", export.record.Message())
+	require.False(t, export.record.Time().Before(before))
+	require.False(t, export.record.Time().After(time.Now()))
+
+	frame, ok := export.record.Caller()
+	require.True(t, ok)
+	require.Contains(t, frame.File, "record_test.go")
+	require.Equal(t, "github.com/yu31/glog.TestRecord_Metadata", frame.Function)
+
+	// The fields is not captured by default.
+	require.Nil(t, export.record.Fields())
+
+	l.WithCaller(false)
+	l.Info().Msg("hello").Fire()
+	_, ok = export.record.Caller()
+	require.False(t, ok)
+}
+
+func TestRecord_Fields(t *testing.T) {
+	export := &CustomExporter{}
+	l := NewDefault().WithExporter(export).WithEncoderFunc(JSONEncoder)
+
+	// The fixed fields added before WithRecordFields(true) are also captured.
+	l.WithFields().AddString("host", "h1")
+	l.WithRecordFields(true)
+	l.Info().Fire()
+	require.Equal(t, []Field{{Key: "host", Type: FieldTypeString, String: "h1"}}, export.record.Fields())
+
+	// Also for the child created by With before it's enabled.
+	child := NewDefault().WithEncoderFunc(JSONEncoder).With().Fields(func(oe ObjectEncoder) error {
+		oe.AddString("zone", "z1")
+		return nil
+	}).Logger()
+	child.WithExporter(export).WithRecordFields(true)
+	child.Info().Fire()
+	require.Equal(t, []Field{{Key: "zone", Type: FieldTypeString, String: "z1"}}, export.record.Fields())
+
+	l.ResetFields().AddString("service", "api")
+	l.Info().Fire()
+	require.Equal(t, []Field{{Key: "service", Type: FieldTypeString, String: "api"}}, export.record.Fields())
+
+	tm := time.Unix(1604484373, 0)
+	err := errors.New("failed")
+	raw := []byte(`{"a":1}`)
+	l.Info().
+		Byte("Byte", 'a').
+		String("String", "s").
+		Bool("Bool", true).
+		Int("Int", -1).
+		Uint64("Uint64", 1<<63).
+		Float64("Float64", 1.5).
+		Complex128("Complex128", 1+2i).
+		RawBytes("RawBytes", raw).
+		RawString("RawString", `"x"`).
+		Time("Time", tm, time.RFC3339).
+		Second("Duration", time.Second).
+		Strings("Strings", []string{"a"}).
+		Error("Error", err).
+		Any("Any", 1).
+		Msg("hello").
+		Fire()
+
+	var keys []string
+	var values []interface{}
+	for _, f := range export.record.Fields() {
+		keys = append(keys, f.Key)
+		values = append(values, f.Value())
+	}
+	require.Equal(t, []string{"Byte", "String", "Bool", "Int", "Uint64", "Float64", "Complex128", "RawBytes",
+		"RawString", "Time", "Duration", "Strings", "Error", "Any", "service"}, keys)
+	require.Equal(t, []interface{}{byte('a'), "s", true, int64(-1), uint64(1 << 63), 1.5, 1 + 2i, raw,
+		`"x"`, tm, time.Second, stringArray{"a"}, export.record.Fields()[12].Interface, 1, "api"}, values)
+	require.Equal(t, FieldTypeObject, export.record.Fields()[12].Type)
+
+	// The encoded content is not changed.
+	require.Contains(t, string(export.record.Bytes()), `"Int":-1`)
+	require.Contains(t, string(export.record.Bytes()), `"service":"api"`)
+
+	// The clone owns the raw bytes.
+	r := export.record.clone()
+	raw[0] = '['
+	require.Equal(t, []byte(`{"a":1}`), r.Fields()[7].Value())
+}
@@ -0,0 +1,110 @@
+package glog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	samplerCounters = 4096
+
+	// FNV-1a 32 bits hash.
+	fnvOffset32 = 2166136261
+	fnvPrime32  = 16777619
+)
+
+// Sampler used to limit the entries with the same level and message,
+// so that a hot loop does not flood the exporter.
+//
+// In every tick, the first N entries with the same level and message are
+// exported, thereafter only every Mth entry is exported.
+// Entries with FatalLevel or higher level are never be sampled.
+type Sampler struct {
+	// sampled and dropped are accessed atomically;
+	// put them in front for 64-bit alignment on 32-bit platforms.
+	sampled uint64
+	dropped uint64
+
+	counters [samplerCounters]samplerCounter
+
+	tick       time.Duration
+	first      uint64
+	thereafter uint64
+
+	// now used to get current time, replaced in tests.
+	now func() time.Time
+}
+
+// NewSampler returns a Sampler that exports the first `first` entries with
+// the same level and message in every tick, and thereafter every `thereafter` entry.
+// If thereafter is zero, all entries after the first will be dropped in the tick.
+func NewSampler(tick time.Duration, first, thereafter int) *Sampler {
+	return &Sampler{
+		tick:       tick,
+		first:      uint64(first),
+		thereafter: uint64(thereafter),
+		now:        time.Now,
+	}
+}
+
+// Sample returns true if the entry with the level and message should be exported.
+func (s *Sampler) Sample(level Level, msg string) bool {
+	if level >= FatalLevel {
+		return true
+	}
+
+	c := &s.counters[samplerHash(level, msg)%samplerCounters]
+	n := c.incCheckReset(s.now(), s.tick)
+	if n <= s.first || (s.thereafter > 0 && (n-s.first)%s.thereafter == 0) {
+		atomic.AddUint64(&s.sampled, 1)
+		return true
+	}
+	atomic.AddUint64(&s.dropped, 1)
+	return false
+}
+
+// Sampled returns the number of entries that have been sampled to export.
+func (s *Sampler) Sampled() uint64 {
+	return atomic.LoadUint64(&s.sampled)
+}
+
+// Dropped returns the number of entries that have been dropped.
+func (s *Sampler) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func samplerHash(level Level, msg string) uint32 {
+	h := uint32(fnvOffset32)
+	h ^= uint32(uint8(level))
+	h *= fnvPrime32
+	for i := 0; i < len(msg); i++ {
+		h ^= uint32(msg[i])
+		h *= fnvPrime32
+	}
+	return h
+}
+
+type samplerCounter struct {
+	resetAt int64
+	counter uint64
+}
+
+// incCheckReset increases the counter and returns the new value;
+// the counter will be reset if the tick has passed.
+func (c *samplerCounter) incCheckReset(t time.Time, tick time.Duration) uint64 {
+	tn := t.UnixNano()
+	resetAfter := atomic.LoadInt64(&c.resetAt)
+	if resetAfter > tn {
+		return atomic.AddUint64(&c.counter, 1)
+	}
+
+	atomic.StoreUint64(&c.counter, 1)
+
+	newResetAfter := tn + tick.Nanoseconds()
+	if !atomic.CompareAndSwapInt64(&c.resetAt, resetAfter, newResetAfter) {
+		// We raced with another goroutine trying to reset, and it also reset
+		// the counter to 1, so we need to reincrement the counter.
+		return atomic.AddUint64(&c.counter, 1)
+	}
+	return 1
+}
@@ -0,0 +1,113 @@
+package glog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSampler(tick time.Duration, first, thereafter int) (*Sampler, *time.Time) {
+	now := time.Date(2020, 11, 4, 18, 0, 0, 0, time.UTC)
+	s := NewSampler(tick, first, thereafter)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestSampler_Sample(t *testing.T) {
+	s, now := newTestSampler(time.Second, 2, 3)
+
+	var got []bool
+	for i := 0; i < 10; i++ {
+		got = append(got, s.Sample(InfoLevel, "msg"))
+	}
+	// first 2, then every 3th.
+	require.Equal(t, []bool{true, true, false, false, true, false, false, true, false, false}, got)
+	require.Equal(t, uint64(4), s.Sampled())
+	require.Equal(t, uint64(6), s.Dropped())
+
+	// The different level or message has its own counter.
+	require.True(t, s.Sample(ErrorLevel, "msg"))
+	require.True(t, s.Sample(InfoLevel, "other msg"))
+
+	// Reset after tick.
+	*now = now.Add(time.Second)
+	require.True(t, s.Sample(InfoLevel, "msg"))
+	require.True(t, s.Sample(InfoLevel, "msg"))
+	require.False(t, s.Sample(InfoLevel, "msg"))
+}
+
+func TestSampler_Thereafter0(t *testing.T) {
+	s, _ := newTestSampler(time.Second, 1, 0)
+	require.True(t, s.Sample(InfoLevel, "msg"))
+	for i := 0; i < 10; i++ {
+		require.False(t, s.Sample(InfoLevel, "msg"))
+	}
+	// Never sample the fatal and panic level.
+	for i := 0; i < 10; i++ {
+		require.True(t, s.Sample(FatalLevel, "msg"))
+		require.True(t, s.Sample(PanicLevel, "msg"))
+	}
+}
+
+func TestSampler_Concurrency(t *testing.T) {
+	s, _ := newTestSampler(time.Minute, 10, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Sample(InfoLevel, "msg")
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, uint64(10), s.Sampled())
+	require.Equal(t, uint64(790), s.Dropped())
+}
+
+func TestLogger_WithSampler(t *testing.T) {
+	var b bytes.Buffer
+	s, now := newTestSampler(time.Second, 1, 2)
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithSampler(s)
+
+	for i := 0; i < 5; i++ {
+		l.Error().Msg("hot error").Fire()
+	}
+	require.Equal(t, 3, strings.Count(b.String(), "hot error"))
+
+	*now = now.Add(time.Second)
+	l.Error().Msg("hot error").Fire()
+	require.Equal(t, 4, strings.Count(b.String(), "hot error"))
+	require.Equal(t, uint64(4), s.Sampled())
+	require.Equal(t, uint64(2), s.Dropped())
+
+	// Disable sampling.
+	l.WithSampler(nil)
+	for i := 0; i < 5; i++ {
+		l.Error().Msg("hot error").Fire()
+	}
+	require.Equal(t, 9, strings.Count(b.String(), "hot error"))
+}
+
+func TestLogger_WithSampler_Fatal(t *testing.T) {
+	var b bytes.Buffer
+	var exited, panicked int
+	s, _ := newTestSampler(time.Second, 1, 0)
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithSampler(s).
+		WithExitFunc(func(code int) { exited++ }).
+		WithPanicFunc(func(v interface{}) { panicked++ })
+
+	// The terminal actions are never dropped by sampling.
+	for i := 0; i < 5; i++ {
+		l.Fatal().Msg("fatal").Fire()
+		l.Panic().Msg("panic").Fire()
+	}
+	require.Equal(t, 5, exited)
+	require.Equal(t, 5, panicked)
+}
@@ -159,7 +159,9 @@ func (vv errorArray) MarshalGLogArray(ae ArrayEncoder) error {
 	for i := range vv {
 		err := vv[i]
 		if err != nil {
-			ae.AppendString(err.Error())
+			if e := ae.AppendObject(errorObject{err: err}); e != nil {
+				return e
+			}
 		} else {
 			ae.AppendString("<nil>")
 		}
@@ -0,0 +1,59 @@
+package glog
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Defines the format type for caller.
+const (
+	// CallerFormatFull formats the caller with the full file path and
+	// function name, e.g. "/go/src/github.com/yu31/glog/logger.go:12"
+	// and "github.com/yu31/glog.(*Logger).Info".
+	CallerFormatFull int8 = iota
+
+	// CallerFormatShort formats the caller with the package and file name and
+	// the function name without package path, e.g. "glog/logger.go:12"
+	// and "glog.(*Logger).Info".
+	CallerFormatShort
+)
+
+// callerFrame returns the frame of caller, the argument skip is the number of
+// stack frames to skip, with 0 identifying the caller of callerFrame.
+func callerFrame(skip int) (frame runtime.Frame, ok bool) {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return
+	}
+	frame, _ = runtime.CallersFrames(pcs[:]).Next()
+	return frame, frame.PC != 0
+}
+
+// CallerFile returns the file path of the caller by the layout.
+func CallerFile(file string, layout int8) string {
+	if layout != CallerFormatShort {
+		return file
+	}
+	// Find the last separator.
+	idx := strings.LastIndexByte(file, '/')
+	if idx == -1 {
+		return file
+	}
+	// Find the penultimate separator.
+	idx = strings.LastIndexByte(file[:idx], '/')
+	if idx == -1 {
+		return file
+	}
+	return file[idx+1:]
+}
+
+// CallerFunc returns the function name of the caller by the layout.
+func CallerFunc(function string, layout int8) string {
+	if layout != CallerFormatShort {
+		return function
+	}
+	if idx := strings.LastIndexByte(function, '/'); idx != -1 {
+		return function[idx+1:]
+	}
+	return function
+}
@@ -0,0 +1,22 @@
+package glog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallerFile(t *testing.T) {
+	file := "/go/src/github.com/yu31/glog/logger.go"
+	require.Equal(t, file, CallerFile(file, CallerFormatFull))
+	require.Equal(t, "glog/logger.go", CallerFile(file, CallerFormatShort))
+	require.Equal(t, "glog/logger.go", CallerFile("glog/logger.go", CallerFormatShort))
+	require.Equal(t, "logger.go", CallerFile("logger.go", CallerFormatShort))
+}
+
+func TestCallerFunc(t *testing.T) {
+	function := "github.com/yu31/glog.(*Logger).Info"
+	require.Equal(t, function, CallerFunc(function, CallerFormatFull))
+	require.Equal(t, "glog.(*Logger).Info", CallerFunc(function, CallerFormatShort))
+	require.Equal(t, "main.main", CallerFunc("main.main", CallerFormatShort))
+}
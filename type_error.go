@@ -0,0 +1,104 @@
+package glog
+
+import (
+	"errors"
+	"reflect"
+)
+
+// maxErrorDepth limits the depth of causes to encode.
+const maxErrorDepth = 32
+
+// errorObject encodes an error as an object with its message and concrete type,
+// or the fields added by the error's ObjectMarshaler instead, and the causes.
+//
+// The causes is a flat list of the errors.Unwrap chain; if an error in the
+// chain is a multi-error, its children are encoded with their own causes.
+type errorObject struct {
+	err   error
+	depth int
+	// leaf indicates whether the causes is omitted.
+	leaf bool
+
+	// msgKey and typeKey are the keys of message and type,
+	// the empty means "msg" and "type"; they're inherited by the causes.
+	msgKey  string
+	typeKey string
+}
+
+func (eo errorObject) MarshalGLogObject(oe ObjectEncoder) error {
+	err := eo.err
+	if om, ok := err.(ObjectMarshaler); ok {
+		// The error's own fields replace the default message and type.
+		if e := om.MarshalGLogObject(oe); e != nil {
+			return e
+		}
+	} else {
+		msgKey, typeKey := eo.msgKey, eo.typeKey
+		if msgKey == "" {
+			msgKey = "msg"
+		}
+		if typeKey == "" {
+			typeKey = "type"
+		}
+		oe.AddString(msgKey, err.Error())
+		oe.AddString(typeKey, reflect.TypeOf(err).String())
+	}
+
+	if eo.leaf || eo.depth >= maxErrorDepth {
+		return nil
+	}
+	if multiErrors(err) == nil && errors.Unwrap(err) == nil {
+		return nil
+	}
+	return oe.AddArray("causes", errorCauses{err: err, depth: eo.depth, msgKey: eo.msgKey, typeKey: eo.typeKey})
+}
+
+type errorCauses struct {
+	err     error
+	depth   int
+	msgKey  string
+	typeKey string
+}
+
+func (ec errorCauses) MarshalGLogArray(ae ArrayEncoder) error {
+	if errs := multiErrors(ec.err); errs != nil {
+		for _, err := range errs {
+			if err == nil {
+				continue
+			}
+			if e := ae.AppendObject(errorObject{err: err, depth: ec.depth + 1, msgKey: ec.msgKey, typeKey: ec.typeKey}); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+
+	depth := ec.depth + 1
+	for err := errors.Unwrap(ec.err); err != nil && depth <= maxErrorDepth; err = errors.Unwrap(err) {
+		// The multi-error terminates the chain; its children are encoded as its causes.
+		isMulti := multiErrors(err) != nil
+		if e := ae.AppendObject(errorObject{
+			err: err, depth: depth, leaf: !isMulti, msgKey: ec.msgKey, typeKey: ec.typeKey,
+		}); e != nil {
+			return e
+		}
+		if isMulti {
+			break
+		}
+		depth++
+	}
+	return nil
+}
+
+// multiErrors returns the children of an error that wraps multiple errors,
+// or nil if it isn't a multi-error.
+func multiErrors(err error) []error {
+	switch m := err.(type) {
+	case interface{ Unwrap() []error }:
+		return m.Unwrap()
+	case interface{ Errors() []error }:
+		return m.Errors()
+	default:
+		return nil
+	}
+}
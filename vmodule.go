@@ -0,0 +1,231 @@
+package glog
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+var _ flag.Value = (*VModule)(nil)
+
+// vmoduleNoMatch is the cached level for the call site that matches no pattern.
+const vmoduleNoMatch = Level(math.MaxInt8)
+
+// VModule is a per-file verbosity spec like the `-vmodule` flag of Google's glog.
+// It enables the entries below the level of Logger for the matching call sites
+// of Logger.V.
+//
+// The spec is a comma-separated list of pattern=N, e.g. "db*=1,net/http/*=trace".
+// The N can be a level name, or a verbosity as in Google's glog: 1 for DebugLevel
+// and 2 or higher for TraceLevel.
+//
+// The pattern is a glob pattern of path.Match that matched against the caller's
+// file name without ".go"; If the pattern contains a slash, it is matched against
+// the same number of trailing elements of the file path, e.g. "db/*" matches
+// the files in the directory "db". The first matching pattern takes effect.
+type VModule struct {
+	// state holds a *vmoduleState, it's replaced by Set.
+	state atomic.Value
+}
+
+// vmoduleVersion is increased on every Set of any VModule, so that the
+// cached decision of VSite is invalidated.
+var vmoduleVersion uint64
+
+type vmoduleState struct {
+	spec  string
+	rules []vmoduleRule
+	// minLevel is the lowest level of rules, the entry below it is never enabled.
+	minLevel Level
+	version  uint64
+}
+
+type vmoduleRule struct {
+	pattern string
+	// elems is the number of path elements in pattern.
+	elems int
+	level Level
+}
+
+// NewVModule returns a VModule with the spec.
+func NewVModule(spec string) (*VModule, error) {
+	vm := &VModule{}
+	if err := vm.Set(spec); err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+// Set replaces the spec at runtime; It implements flag.Value.
+func (vm *VModule) Set(spec string) error {
+	rules, err := parseVModule(spec)
+	if err != nil {
+		return err
+	}
+	st := &vmoduleState{
+		spec:     spec,
+		rules:    rules,
+		minLevel: vmoduleNoMatch,
+		version:  atomic.AddUint64(&vmoduleVersion, 1),
+	}
+	for i := range rules {
+		if rules[i].level.rank() < st.minLevel.rank() {
+			st.minLevel = rules[i].level
+		}
+	}
+	vm.state.Store(st)
+	return nil
+}
+
+// String returns the spec; It implements flag.Value.
+func (vm *VModule) String() string {
+	if vm == nil {
+		return ""
+	}
+	if st, ok := vm.state.Load().(*vmoduleState); ok {
+		return st.spec
+	}
+	return ""
+}
+
+func parseVModule(spec string) ([]vmoduleRule, error) {
+	var rules []vmoduleRule
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		i := strings.LastIndexByte(s, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("glog: invalid vmodule %q", s)
+		}
+		pattern, value := strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+		if pattern == "" || value == "" {
+			return nil, fmt.Errorf("glog: invalid vmodule %q", s)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("glog: invalid vmodule pattern %q: %v", pattern, err)
+		}
+		level, err := parseVerbosity(value)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, vmoduleRule{
+			pattern: pattern,
+			elems:   strings.Count(pattern, "/") + 1,
+			level:   level,
+		})
+	}
+	return rules, nil
+}
+
+// parseVerbosity parses the verbosity or level name.
+func parseVerbosity(value string) (Level, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return ParseLevel(value)
+	}
+	switch {
+	case n <= 0:
+		return InfoLevel, nil
+	case n == 1:
+		return DebugLevel, nil
+	default:
+		return TraceLevel, nil
+	}
+}
+
+// match returns the level of the first rule that matches the file.
+func (st *vmoduleState) match(file string) Level {
+	file = strings.TrimSuffix(file, ".go")
+	for i := range st.rules {
+		r := &st.rules[i]
+		if ok, _ := path.Match(r.pattern, trailingElems(file, r.elems)); ok {
+			return r.level
+		}
+	}
+	return vmoduleNoMatch
+}
+
+// trailingElems returns the last n elements of the slash-separated path.
+func trailingElems(p string, n int) string {
+	for i := len(p) - 1; i >= 0; i-- {
+		if p[i] == '/' {
+			if n--; n == 0 {
+				return p[i+1:]
+			}
+		}
+	}
+	return p
+}
+
+// VSite caches the decision of VModule for a call site of Logger.V, so that
+// the cost after the first call is an atomic load. It's usually declared as
+// a package-level variable, and must be used in only one file, e.g.
+//
+//	var site glog.VSite
+//
+//	l.V(&site, glog.DebugLevel).Msg("HelloWorld").Fire()
+type VSite struct {
+	// v packs the version of vmoduleState and the level of the call site,
+	// accessed atomically; The zero value means not resolved.
+	v uint64
+}
+
+const vsiteShift = 8
+
+// enabled returns true if the entry with level is enabled for the call site,
+// the argument skip is the number of stack frames to skip, with 0 identifying
+// the caller of enabled.
+func (vm *VModule) enabled(site *VSite, level Level, skip int) bool {
+	st, ok := vm.state.Load().(*vmoduleState)
+	if !ok || level.rank() < st.minLevel.rank() {
+		return false
+	}
+	v := atomic.LoadUint64(&site.v)
+	if v>>vsiteShift != st.version {
+		v = site.resolve(st, skip+1)
+	}
+	return level.rank() >= Level(int8(uint8(v))).rank()
+}
+
+// resolve matches the file of the call site and caches the level in site.
+func (site *VSite) resolve(st *vmoduleState, skip int) uint64 {
+	lv := vmoduleNoMatch
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 1 {
+		frame, _ := runtime.CallersFrames(pcs[:]).Next()
+		lv = st.match(frame.File)
+	}
+	v := st.version<<vsiteShift | uint64(uint8(lv))
+	atomic.StoreUint64(&site.v, v)
+	return v
+}
+
+// WithVModule will reset logger's vmodule, nil means disable it.
+//
+// The vmodule is consulted only by Logger.V if the entry is below the level of Logger.
+func (l *Logger) WithVModule(vm *VModule) *Logger {
+	return l.update(func(c *loggerCore) { c.vmodule = vm })
+}
+
+// V returns an Entry with level like the level methods, e.g. Debug; The entry
+// below the level of Logger is enabled if the vmodule matches the file of the
+// call site, and the decision is cached in site.
+func (l *Logger) V(site *VSite, level Level) *Entry {
+	return l.newVEntry(site, level)
+}
+
+func (l *Logger) newVEntry(site *VSite, level Level) *Entry {
+	c := l.load()
+	// Skip the frames of newVEntry and V.
+	if c.vmodule != nil && !c.enabled(level) && c.vmodule.enabled(site, level, 2+c.callerSkip) {
+		return newEntry(l, c, level)
+	}
+	return l.newEntry(level)
+}
@@ -0,0 +1,161 @@
+package glog
+
+import (
+	"bytes"
+	"flag"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVModule(t *testing.T) {
+	vm, err := NewVModule(" db*=1, net/http/*=trace ,x=0,")
+	require.Nil(t, err)
+	require.Equal(t, " db*=1, net/http/*=trace ,x=0,", vm.String())
+
+	st := vm.state.Load().(*vmoduleState)
+	require.Equal(t, []vmoduleRule{
+		{pattern: "db*", elems: 1, level: DebugLevel},
+		{pattern: "net/http/*", elems: 3, level: TraceLevel},
+		{pattern: "x", elems: 1, level: InfoLevel},
+	}, st.rules)
+
+	require.Equal(t, DebugLevel, st.match("/src/app/db_conn.go"))
+	require.Equal(t, TraceLevel, st.match("/go/src/net/http/server.go"))
+	require.Equal(t, vmoduleNoMatch, st.match("/src/app/http/server.go"))
+	require.Equal(t, vmoduleNoMatch, st.match("/src/db/conn.go"))
+
+	for _, spec := range []string{"db", "=1", "db=", "db=xx", "[=1"} {
+		_, err := NewVModule(spec)
+		require.NotNil(t, err, spec)
+	}
+
+	require.Equal(t, "c", trailingElems("/a/b/c", 1))
+	require.Equal(t, "b/c", trailingElems("/a/b/c", 2))
+	require.Equal(t, "a/b/c", trailingElems("a/b/c", 5))
+}
+
+var testSiteTrace, testSiteDebug VSite
+
+func TestLogger_WithVModule(t *testing.T) {
+	var b bytes.Buffer
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithLevel(InfoLevel)
+
+	vm, err := NewVModule("other=2")
+	require.Nil(t, err)
+	l.WithVModule(vm)
+
+	logAll := func() {
+		b.Reset()
+		for i := 0; i < 3; i++ {
+			l.V(&testSiteTrace, TraceLevel).Msg("trace").Fire()
+			l.V(&testSiteDebug, DebugLevel).Msg("debug").Fire()
+			// The level methods are not affected.
+			l.Debug().Msg("debug").Fire()
+		}
+	}
+	logAll()
+	require.Equal(t, "", b.String())
+
+	// The spec is changed at runtime.
+	require.Nil(t, vm.Set("vmodule_test=1"))
+	logAll()
+	require.Equal(t, 0, bytes.Count(b.Bytes(), []byte("[trace]")))
+	require.Equal(t, 3, bytes.Count(b.Bytes(), []byte("[debug]")))
+
+	require.Nil(t, vm.Set("*/vmodule_*=trace"))
+	logAll()
+	require.Equal(t, 3, bytes.Count(b.Bytes(), []byte("[trace]")))
+	require.Equal(t, 3, bytes.Count(b.Bytes(), []byte("[debug]")))
+
+	// The entries enabled by level are not affected.
+	b.Reset()
+	l.V(&testSiteDebug, InfoLevel).Msg("info").Fire()
+	require.Contains(t, b.String(), "[info] info")
+
+	// The call site of the wrapper is skipped by callerSkip.
+	require.Nil(t, vm.Set("vmodule_test=1"))
+	b.Reset()
+	debugf(l.Clone().WithCallerSkip(1), "wrapped")
+	require.Contains(t, b.String(), "[debug] wrapped")
+
+	// Used as command-line flag.
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(vm, "vmodule", "")
+	require.Nil(t, fs.Parse([]string{"-vmodule=other=1"}))
+	logAll()
+	require.Equal(t, "", b.String())
+
+	l.WithVModule(nil)
+	require.Nil(t, vm.Set("vmodule_test=1"))
+	logAll()
+	require.Equal(t, "", b.String())
+}
+
+func TestVSite_Cached(t *testing.T) {
+	var b bytes.Buffer
+	vm, err := NewVModule("vmodule_test=1")
+	require.Nil(t, err)
+	l := NewDefault().WithExporter(StandardExporter(&b)).WithLevel(InfoLevel).WithVModule(vm)
+
+	var site VSite
+	logDebug := func() { l.V(&site, DebugLevel).Msg("debug").Fire() }
+	logDebug()
+	require.Contains(t, b.String(), "[debug] debug")
+
+	// The call site is not resolved again after the first call, the cached
+	// decision is used until the spec is changed.
+	st := vm.state.Load().(*vmoduleState)
+	require.Equal(t, st.version<<vsiteShift|uint64(uint8(DebugLevel)), site.v)
+	site.v = st.version<<vsiteShift | uint64(uint8(vmoduleNoMatch))
+	b.Reset()
+	logDebug()
+	require.Equal(t, "", b.String())
+
+	require.Nil(t, vm.Set("vmodule_test=1"))
+	logDebug()
+	require.Contains(t, b.String(), "[debug] debug")
+
+	// The call site is not resolved if no rule could enable the level.
+	v := site.v
+	require.Nil(t, vm.Set("vmodule_test=1"))
+	l.V(&site, TraceLevel).Msg("trace").Fire()
+	require.Equal(t, v, site.v)
+
+	require.Nil(t, vm.Set("other=1"))
+	require.Equal(t, float64(0), testing.AllocsPerRun(100, func() {
+		l.V(&site, DebugLevel).Msg("debug").Fire()
+	}))
+}
+
+func TestLogger_WithVModule_Concurrency(t *testing.T) {
+	var exported uint64
+	vm, err := NewVModule("vmodule_test=1")
+	require.Nil(t, err)
+	l := NewDefault().WithExporter(&countExporter{n: &exported}).WithLevel(InfoLevel).WithVModule(vm)
+
+	var site VSite
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.V(&site, DebugLevel).Msg("debug").Fire()
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		require.Nil(t, vm.Set("vmodule_test=1"))
+	}
+	wg.Wait()
+	require.Equal(t, uint64(400), exported)
+}
+
+var testSiteWrapper VSite
+
+// debugf is a wrapper of Logger used to test the callerSkip.
+func debugf(l *Logger, msg string) {
+	l.V(&testSiteWrapper, DebugLevel).Msg(msg).Fire()
+}